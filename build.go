@@ -2,11 +2,15 @@ package dotnetcoreruntime
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Masterminds/semver"
+	"github.com/paketo-buildpacks/dotnet-core-runtime/internal/manifest"
 	"github.com/paketo-buildpacks/packit/v2"
 	"github.com/paketo-buildpacks/packit/v2/chronos"
 	"github.com/paketo-buildpacks/packit/v2/postal"
@@ -28,12 +32,12 @@ type DependencyManager interface {
 
 //go:generate faux --interface DotnetSymlinker --output fakes/dotnet_symlinker.go
 type DotnetSymlinker interface {
-	Link(workingDir, layerPath string) (Err error)
+	Link(dotnetRoot, layerPath string) (Err error)
 }
 
 //go:generate faux --interface VersionResolver --output fakes/version_resolver.go
 type VersionResolver interface {
-	Resolve(path string, entry packit.BuildpackPlanEntry, stack string) (postal.Dependency, error)
+	Resolve(path string, entry packit.BuildpackPlanEntry, stack, platformDir string) (postal.Dependency, error)
 }
 
 //go:generate faux --interface SBOMGenerator --output fakes/sbom_generator.go
@@ -41,6 +45,20 @@ type SBOMGenerator interface {
 	GenerateFromDependency(dependency postal.Dependency, dir string) (sbom.SBOM, error)
 }
 
+// Priorities is the order in which the version sources of dotnet-runtime
+// requirements are ranked when selecting the version to install.
+var Priorities = []interface{}{
+	"BP_DOTNET_FRAMEWORK_VERSION",
+	".dotnet-version",
+	".tool-versions",
+	"buildpack.yml",
+	regexp.MustCompile(`.*\.(cs)|(fs)|(vb)proj`),
+	regexp.MustCompile(`\.(props|targets)$`),
+	"runtimeconfig.json",
+	regexp.MustCompile(`\.deps\.json$`),
+	"global.json",
+}
+
 func Build(
 	entries EntryResolver,
 	dependencies DependencyManager,
@@ -54,15 +72,76 @@ func Build(
 		logger.Title("%s %s", context.BuildpackInfo.Name, context.BuildpackInfo.Version)
 		logger.Process("Resolving .NET Core Runtime version")
 
-		priorities := []interface{}{
-			"BP_DOTNET_FRAMEWORK_VERSION",
-			"buildpack.yml",
-			regexp.MustCompile(`.*\.(cs)|(fs)|(vb)proj`),
-			"runtimeconfig.json",
-		}
-		entry, sortedEntries := entries.Resolve("dotnet-runtime", context.Plan.Entries, priorities)
+		entry, sortedEntries := entries.Resolve("dotnet-runtime", context.Plan.Entries, Priorities)
 		logger.Candidates(sortedEntries)
 
+		launch, build := entries.MergeLayerTypes("dotnet-runtime", context.Plan.Entries)
+
+		projectDir, err := projectDirectory(context.WorkingDir)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		forceInstall, err := parseBoolEnv("BP_DOTNET_RUNTIME_FORCE_INSTALL")
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		// A forced installation is of no use unless the runtime is available
+		// when the application starts.
+		if forceInstall {
+			launch = true
+		}
+
+		// The download cache is kept whether or not the runtime is installed,
+		// so that it survives builds that skip the installation.
+		downloadsLayer, err := context.Layers.Get(DownloadCacheLayerName)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		cacheSize, err := downloadCacheSize()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		skip, err := skipSelfContained(projectDir, launch, build)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		if skip {
+			dotnetCoreRuntimeLayer, report, err := skipInstallation(context, entry, sortedEntries, logger)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			layers, err := resultLayers(context, report, downloadsLayer, cacheSize, "", logger, dotnetCoreRuntimeLayer)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			return packit.BuildResult{Layers: layers}, nil
+		}
+
+		configuredTypes, err := configuredLayerTypes()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		if len(configuredTypes) > 0 {
+			logger.Process("Layer types configured by BP_DOTNET_RUNTIME_LAUNCH and BP_DOTNET_RUNTIME_BUILD")
+			logger.Subprocess("Available at app launch: %t", launch)
+			logger.Subprocess("Available to other buildpacks: %t", build)
+			logger.Break()
+		}
+
+		if !launch && !build {
+			logger.Process("WARNING: nothing requires .NET Core Runtime at launch or build time, so it will be neither included in the image nor cached")
+			logger.Subprocess("Set BP_DOTNET_RUNTIME_LAUNCH=true or BP_DOTNET_RUNTIME_BUILD=true to install it for launch or build")
+			logger.Break()
+		}
+
 		source, _ := entry.Metadata["version-source"].(string)
 		if source == "buildpack.yml" {
 			nextMajorVersion := semver.MustParse(context.BuildpackInfo.Version).IncMajor()
@@ -71,20 +150,68 @@ func Build(
 			logger.Break()
 		}
 
-		dependency, err := versionResolver.Resolve(filepath.Join(context.CNBPath, "buildpack.toml"), entry, context.Stack)
+		dependency, err := versionResolver.Resolve(filepath.Join(context.CNBPath, "buildpack.toml"), entry, context.Stack, context.Platform.Path)
 		if err != nil {
 			return packit.BuildResult{}, err
 		}
 
 		logger.SelectedDependency(entry, dependency, clock.Now())
 
+		crossCheckDepsJSON(projectDir, logger)
+
+		err = checkRuntimeConfigs(projectDir, dependency, logger)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
 		dotnetCoreRuntimeLayer, err := context.Layers.Get("dotnet-core-runtime")
 		if err != nil {
 			return packit.BuildResult{}, err
 		}
 
 		bom := dependencies.GenerateBillOfMaterials(dependency)
-		launch, build := entries.MergeLayerTypes("dotnet-runtime", context.Plan.Entries)
+
+		requestedVersion, _ := entry.Metadata["version"].(string)
+		report := RuntimeReport{
+			VersionSource:    source,
+			RequestedVersion: requestedVersion,
+			Candidates:       newRuntimeReportCandidates(sortedEntries),
+			Dependency:       newRuntimeReportDependency(dependency, clock.Now()),
+			RollForward:      rollForwardSteps(requestedVersion, source, dependency.Version),
+			Launch:           launch,
+			Build:            build,
+		}
+
+		disableDiagnostics, err := parseBoolEnv("BP_DOTNET_RUNTIME_DISABLE_DIAGNOSTICS")
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		verifyHashes, err := parseBoolEnv("BP_DOTNET_RUNTIME_VERIFY_HASHES")
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		dotnetRoot, err := resolveDotnetRoot(context.WorkingDir, dotnetCoreRuntimeLayer.Path)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		dryRun, err := parseBoolEnv("BP_DOTNET_RUNTIME_DRY_RUN")
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		if dryRun {
+			report, runtimeLayers := planDryRun(dotnetCoreRuntimeLayer, dependency, report, dotnetRoot, disableDiagnostics, verifyHashes, logger)
+
+			layers, err := resultLayers(context, report, downloadsLayer, cacheSize, dependencySHA256(dependency), logger, runtimeLayers...)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			return packit.BuildResult{Layers: layers}, nil
+		}
 
 		var buildMetadata packit.BuildMetadata
 		if build {
@@ -96,24 +223,65 @@ func Build(
 			launchMetadata.BOM = bom
 		}
 
-		cachedDependencySHA, ok := dotnetCoreRuntimeLayer.Metadata["dependency-sha"]
-		if ok && cachedDependencySHA == dependency.SHA256 { //nolint:staticcheck
+		trimPatterns, err := trimConfiguration()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		// Layers are only reused when they were trimmed the same way, carry the
+		// same diagnostics default and DOTNET_ROOT, and their manifest records
+		// checksums if and only if they are requested.
+		trimKey := strings.Join(trimPatterns, ",")
+		cachedTrimKey, _ := dotnetCoreRuntimeLayer.Metadata["trim-patterns"].(string)
+		cachedDisableDiagnostics, _ := dotnetCoreRuntimeLayer.Metadata["diagnostics-disabled"].(bool)
+		cachedVerifyHashes, _ := dotnetCoreRuntimeLayer.Metadata["manifest-hashes"].(bool)
+		cachedDotnetRoot, ok := dotnetCoreRuntimeLayer.Metadata["dotnet-root"].(string)
+		if !ok {
+			cachedDotnetRoot = filepath.Join(context.WorkingDir, ".dotnet_root")
+		}
+
+		// The exec.d binaries are copied into reused layers as well, so that a
+		// reused layer runs the binaries of the current buildpack version.
+		execD := []string{
+			filepath.Join(context.CNBPath, "bin", "verify-runtime"),
+			filepath.Join(context.CNBPath, "bin", "configure-diagnostics"),
+		}
+
+		if layerHasDependency(dotnetCoreRuntimeLayer.Metadata, dependency) && cachedTrimKey == trimKey && cachedDisableDiagnostics == disableDiagnostics && cachedVerifyHashes == verifyHashes && cachedDotnetRoot == dotnetRoot {
 			logger.Process(fmt.Sprintf("Reusing cached layer %s", dotnetCoreRuntimeLayer.Path))
 			logger.Break()
 
-			err = dotnetSymlinker.Link(context.WorkingDir, dotnetCoreRuntimeLayer.Path)
-			if err != nil {
-				return packit.BuildResult{}, err
+			if dotnetRoot != dotnetCoreRuntimeLayer.Path {
+				err = dotnetSymlinker.Link(dotnetRoot, dotnetCoreRuntimeLayer.Path)
+				if err != nil {
+					return packit.BuildResult{}, err
+				}
 			}
 
 			dotnetCoreRuntimeLayer.Launch, dotnetCoreRuntimeLayer.Build, dotnetCoreRuntimeLayer.Cache = launch, build, build
+			dotnetCoreRuntimeLayer.ExecD = execD
+			logger.LayerFlags(dotnetCoreRuntimeLayer)
+
+			report.CacheReused = true
+			if cachedTrimmedFiles, ok := dotnetCoreRuntimeLayer.Metadata["trimmed-files"].([]interface{}); ok {
+				for _, file := range cachedTrimmedFiles {
+					if file, ok := file.(string); ok {
+						report.TrimmedFiles = append(report.TrimmedFiles, file)
+					}
+				}
+			}
+			report.LaunchEnv, report.BuildEnv = dotnetCoreRuntimeLayer.LaunchEnv, dotnetCoreRuntimeLayer.BuildEnv
+
+			layers, err := resultLayers(context, report, downloadsLayer, cacheSize, dependencySHA256(dependency), logger, dotnetCoreRuntimeLayer)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
 
 			return packit.BuildResult{
-				Layers: []packit.Layer{dotnetCoreRuntimeLayer},
+				Layers: layers,
 				Build:  buildMetadata,
 				Launch: launchMetadata,
 			}, nil
-
 		}
 
 		logger.Process("Executing build process")
@@ -124,6 +292,16 @@ func Build(
 		}
 
 		dotnetCoreRuntimeLayer.Launch, dotnetCoreRuntimeLayer.Build, dotnetCoreRuntimeLayer.Cache = launch, build, build
+		logger.LayerFlags(dotnetCoreRuntimeLayer)
+
+		// The dependency manager keeps downloaded tarballs in the download cache
+		// layer when it exists.
+		if cacheSize > 0 {
+			err = os.MkdirAll(downloadsLayer.Path, os.ModePerm)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+		}
 
 		logger.Subprocess("Installing .NET Core Runtime %s", dependency.Version)
 		duration, err := clock.Measure(func() error {
@@ -136,19 +314,56 @@ func Build(
 		logger.Action("Completed in %s", duration.Round(time.Millisecond))
 		logger.Break()
 
+		report.InstallDuration = duration.Milliseconds()
+
 		dotnetCoreRuntimeLayer.Metadata = map[string]interface{}{
-			"dependency-sha": dependency.SHA256, //nolint:staticcheck
+			"dependency-checksum": dependencyChecksum(dependency),
+		}
+
+		if disableDiagnostics {
+			dotnetCoreRuntimeLayer.Metadata["diagnostics-disabled"] = true
 		}
 
-		err = dotnetSymlinker.Link(context.WorkingDir, dotnetCoreRuntimeLayer.Path)
+		if verifyHashes {
+			dotnetCoreRuntimeLayer.Metadata["manifest-hashes"] = true
+		}
+
+		if len(trimPatterns) > 0 {
+			logger.Subprocess("Trimming .NET Core Runtime (%s)", trimKey)
+			trimmedFiles, err := TrimRuntime(dotnetCoreRuntimeLayer.Path, trimPatterns)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			for _, file := range trimmedFiles {
+				logger.Action("Removed %s", file)
+			}
+			logger.Break()
+
+			dotnetCoreRuntimeLayer.Metadata["trim-patterns"] = trimKey
+			dotnetCoreRuntimeLayer.Metadata["trimmed-files"] = trimmedFiles
+			report.TrimmedFiles = trimmedFiles
+		}
+
+		err = manifest.Write(dotnetCoreRuntimeLayer.Path, dependency.Version, verifyHashes)
 		if err != nil {
 			return packit.BuildResult{}, err
 		}
 
-		// Set DOTNET_ROOT to the symlink directory in the working directory, instead of setting it to  the layer path itself.
-		dotnetCoreRuntimeLayer.LaunchEnv.Override("DOTNET_ROOT", filepath.Join(context.WorkingDir, ".dotnet_root"))
+		dotnetCoreRuntimeLayer.ExecD = execD
+
+		if dotnetRoot != filepath.Join(context.WorkingDir, ".dotnet_root") {
+			dotnetCoreRuntimeLayer.Metadata["dotnet-root"] = dotnetRoot
+		}
+
+		if dotnetRoot != dotnetCoreRuntimeLayer.Path {
+			err = dotnetSymlinker.Link(dotnetRoot, dotnetCoreRuntimeLayer.Path)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+		}
 
-		dotnetCoreRuntimeLayer.BuildEnv.Override("RUNTIME_VERSION", dependency.Version)
+		setRuntimeEnvironment(dotnetCoreRuntimeLayer, dotnetRoot, dependency, disableDiagnostics, verifyHashes)
 
 		logger.EnvironmentVariables(dotnetCoreRuntimeLayer)
 
@@ -165,16 +380,269 @@ func Build(
 		logger.Action("Completed in %s", duration.Round(time.Millisecond))
 		logger.Break()
 
+		report.SBOMDuration = duration.Milliseconds()
+
 		logger.FormattingSBOM(context.BuildpackInfo.SBOMFormats...)
 		dotnetCoreRuntimeLayer.SBOM, err = sbomContent.InFormats(context.BuildpackInfo.SBOMFormats...)
 		if err != nil {
 			return packit.BuildResult{}, err
 		}
 
+		report.LaunchEnv, report.BuildEnv = dotnetCoreRuntimeLayer.LaunchEnv, dotnetCoreRuntimeLayer.BuildEnv
+
+		layers, err := resultLayers(context, report, downloadsLayer, cacheSize, dependencySHA256(dependency), logger, dotnetCoreRuntimeLayer)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
 		return packit.BuildResult{
-			Layers: []packit.Layer{dotnetCoreRuntimeLayer},
+			Layers: layers,
 			Build:  buildMetadata,
 			Launch: launchMetadata,
 		}, nil
 	}
 }
+
+// skipInstallation clears the runtime layer of a self-contained application,
+// for which installing the runtime is skipped, and returns it along with the
+// report of the build.
+func skipInstallation(context packit.BuildContext, entry packit.BuildpackPlanEntry, sortedEntries []packit.BuildpackPlanEntry, logger scribe.Emitter) (packit.Layer, RuntimeReport, error) {
+	logger.Process("Skipping installation of .NET Core Runtime: the application is self-contained and nothing requires the runtime at launch or build time")
+	logger.Subprocess("Set BP_DOTNET_RUNTIME_FORCE_INSTALL=true to install it anyway")
+	logger.Break()
+
+	dotnetCoreRuntimeLayer, err := context.Layers.Get("dotnet-core-runtime")
+	if err != nil {
+		return packit.Layer{}, RuntimeReport{}, err
+	}
+
+	// Clear any runtime left over from a build before the application became
+	// self-contained so that it is not exported again.
+	dotnetCoreRuntimeLayer, err = dotnetCoreRuntimeLayer.Reset()
+	if err != nil {
+		return packit.Layer{}, RuntimeReport{}, err
+	}
+
+	requestedVersion, _ := entry.Metadata["version"].(string)
+	source, _ := entry.Metadata["version-source"].(string)
+
+	return dotnetCoreRuntimeLayer, RuntimeReport{
+		SelfContained:    true,
+		VersionSource:    source,
+		RequestedVersion: requestedVersion,
+		Candidates:       newRuntimeReportCandidates(sortedEntries),
+	}, nil
+}
+
+// planDryRun logs the environment that installing the dependency would
+// configure and records it in the report, without installing the runtime. A
+// runtime installed by an earlier build is returned unchanged, with its
+// metadata and the layer types a full build would set, so that the next build
+// can still reuse it.
+func planDryRun(dotnetCoreRuntimeLayer packit.Layer, dependency postal.Dependency, report RuntimeReport, dotnetRoot string, disableDiagnostics, verifyHashes bool, logger scribe.Emitter) (RuntimeReport, []packit.Layer) {
+	logger.Process("Dry run: skipping installation of .NET Core Runtime %s", dependency.Version)
+	logger.Break()
+
+	plannedLayer := packit.Layer{
+		Name:      dotnetCoreRuntimeLayer.Name,
+		Path:      dotnetCoreRuntimeLayer.Path,
+		LaunchEnv: packit.Environment{},
+		BuildEnv:  packit.Environment{},
+	}
+	setRuntimeEnvironment(plannedLayer, dotnetRoot, dependency, disableDiagnostics, verifyHashes)
+
+	logger.EnvironmentVariables(plannedLayer)
+
+	report.DryRun = true
+	report.LaunchEnv, report.BuildEnv = plannedLayer.LaunchEnv, plannedLayer.BuildEnv
+
+	if len(dotnetCoreRuntimeLayer.Metadata) == 0 {
+		return report, nil
+	}
+
+	dotnetCoreRuntimeLayer.Launch, dotnetCoreRuntimeLayer.Build, dotnetCoreRuntimeLayer.Cache = report.Launch, report.Build, report.Build
+	return report, []packit.Layer{dotnetCoreRuntimeLayer}
+}
+
+// resultLayers writes the runtime report and prunes the download cache, never
+// removing the tarball with the given SHA256. It returns the given layers
+// followed by the report and download cache layers, as the build returns them.
+func resultLayers(context packit.BuildContext, report RuntimeReport, downloadsLayer packit.Layer, cacheSize int64, keep string, logger scribe.Emitter, layers ...packit.Layer) ([]packit.Layer, error) {
+	reportLayers, err := emitReport(context, report, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheLayers, err := retainDownloadCache(downloadsLayer, cacheSize, keep, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append(layers, reportLayers...), cacheLayers...), nil
+}
+
+func setRuntimeEnvironment(layer packit.Layer, dotnetRoot string, dependency postal.Dependency, disableDiagnostics, verifyHashes bool) {
+	layer.LaunchEnv.Override("DOTNET_ROOT", dotnetRoot)
+
+	// Tell the exec.d verifier which runtime was installed and where its
+	// manifest lives.
+	layer.LaunchEnv.Override("BPI_DOTNET_RUNTIME_VERSION", dependency.Version)
+	layer.LaunchEnv.Override("BPI_DOTNET_RUNTIME_MANIFEST", filepath.Join(layer.Path, manifest.Name))
+
+	// Checksums recorded at build time are verified at launch unless
+	// BPL_DOTNET_RUNTIME_VERIFY_HASHES turns it off again.
+	if verifyHashes {
+		layer.LaunchEnv.Default("BPL_DOTNET_RUNTIME_VERIFY_HASHES", "true")
+	}
+
+	// Hardened images close the diagnostics IPC channel unless it is turned
+	// back on at launch with BPL_DOTNET_DIAGNOSTICS.
+	if disableDiagnostics {
+		layer.LaunchEnv.Default("DOTNET_EnableDiagnostics", "0")
+	}
+
+	layer.BuildEnv.Override("RUNTIME_VERSION", dependency.Version)
+}
+
+// resolveDotnetRoot returns the directory that DOTNET_ROOT points at. By
+// default it is a .dotnet_root symlink tree in the working directory, which
+// other .NET buildpacks link their frameworks into as well.
+// BP_DOTNET_ROOT=layer points DOTNET_ROOT at the runtime layer itself, and
+// any other value is the location of the symlink tree, which must be inside
+// the working directory so that it is exported with the application.
+func resolveDotnetRoot(workingDir, layerPath string) (string, error) {
+	value := os.Getenv("BP_DOTNET_ROOT")
+	switch value {
+	case "":
+		return filepath.Join(workingDir, ".dotnet_root"), nil
+	case "layer":
+		return layerPath, nil
+	}
+
+	dotnetRoot := value
+	if !filepath.IsAbs(dotnetRoot) {
+		dotnetRoot = filepath.Join(workingDir, dotnetRoot)
+	}
+	dotnetRoot = filepath.Clean(dotnetRoot)
+
+	rel, err := filepath.Rel(workingDir, dotnetRoot)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid BP_DOTNET_ROOT value %q: must be \"layer\" or a directory inside the application directory %s", value, workingDir)
+	}
+
+	return dotnetRoot, nil
+}
+
+// skipSelfContained reports whether installing the runtime can be skipped
+// because the application in the project directory is self-contained and no
+// buildpack requires the runtime at launch or build time.
+func skipSelfContained(projectDir string, launch, build bool) (bool, error) {
+	if launch || build {
+		return false, nil
+	}
+
+	return IsSelfContained(projectDir)
+}
+
+// layerHasDependency reports whether the metadata of a layer records that the
+// given dependency is installed in it. Layers written by earlier versions of
+// the buildpack record the SHA256 of the dependency as dependency-sha instead
+// of its checksum.
+func layerHasDependency(metadata map[string]interface{}, dependency postal.Dependency) bool {
+	if checksum, ok := metadata["dependency-checksum"]; ok {
+		return checksum == dependencyChecksum(dependency)
+	}
+
+	sha, _ := metadata["dependency-sha"].(string)
+	return sha != "" && strings.EqualFold(sha, dependencySHA256(dependency))
+}
+
+// dependencyChecksum identifies the artifact of a dependency by its checksum,
+// or by its SHA256 for entries that only carry the deprecated field.
+func dependencyChecksum(dependency postal.Dependency) string {
+	if dependency.Checksum != "" {
+		return dependency.Checksum
+	}
+	return dependency.SHA256 //nolint:staticcheck
+}
+
+// crossCheckDepsJSON warns when the .deps.json of an application disagrees
+// with its runtimeconfig.json, which usually means stale publish output. The
+// runtimeconfig.json is what the host uses, so this never fails the build,
+// not even when the files cannot be parsed.
+func crossCheckDepsJSON(projectDir string, logger scribe.Emitter) {
+	mismatches, err := CrossCheckDepsJSON(projectDir)
+	if err != nil {
+		logger.Subprocess("WARNING: failed to compare .deps.json with runtimeconfig.json: %s", err)
+		logger.Break()
+		return
+	}
+
+	for _, mismatch := range mismatches {
+		logger.Subprocess("WARNING: %s", mismatch)
+	}
+	if len(mismatches) > 0 {
+		logger.Break()
+	}
+}
+
+// checkRuntimeConfigs warns, or fails the build, when the application's
+// *.runtimeconfig.json files require a framework that the selected runtime
+// cannot satisfy. BP_DOTNET_RUNTIME_CONFIG_CHECK selects the behaviour.
+func checkRuntimeConfigs(projectDir string, dependency postal.Dependency, logger scribe.Emitter) error {
+	mode := strings.ToLower(os.Getenv("BP_DOTNET_RUNTIME_CONFIG_CHECK"))
+	switch mode {
+	case "":
+		// The resolver may roll forward to a version that the host policy of
+		// a runtimeconfig.json does not allow, so a mismatch only fails the
+		// build when requested.
+		mode = "warn"
+	case "fail", "warn", "off":
+	default:
+		return fmt.Errorf("invalid BP_DOTNET_RUNTIME_CONFIG_CHECK value %q: must be one of fail, warn or off", mode)
+	}
+
+	if mode == "off" {
+		return nil
+	}
+
+	mismatches, err := CheckRuntimeConfigs(projectDir, dependency.Version)
+	if err != nil {
+		return err
+	}
+
+	if len(mismatches) == 0 {
+		return nil
+	}
+
+	if mode == "warn" {
+		for _, mismatch := range mismatches {
+			logger.Subprocess("WARNING: %s", mismatch)
+		}
+		logger.Break()
+		return nil
+	}
+
+	var messages []string
+	for _, mismatch := range mismatches {
+		messages = append(messages, mismatch.String())
+	}
+
+	return fmt.Errorf("the selected .NET Core Runtime cannot run this application:\n  %s\nSelect a compatible version with BP_DOTNET_FRAMEWORK_VERSION, or set BP_DOTNET_RUNTIME_CONFIG_CHECK=warn to continue anyway", strings.Join(messages, "\n  "))
+}
+
+// parseBoolEnv returns the boolean value of the named environment variable,
+// or false when it is unset or empty.
+func parseBoolEnv(name string) (bool, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return false, nil
+	}
+
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse %s value %q: %w", name, value, err)
+	}
+
+	return enabled, nil
+}
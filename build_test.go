@@ -2,13 +2,17 @@ package dotnetcoreruntime_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	dotnetcoreruntime "github.com/paketo-buildpacks/dotnet-core-runtime"
 	"github.com/paketo-buildpacks/dotnet-core-runtime/fakes"
+	"github.com/paketo-buildpacks/dotnet-core-runtime/internal/manifest"
 	"github.com/paketo-buildpacks/packit/v2"
 	"github.com/paketo-buildpacks/packit/v2/chronos"
 	"github.com/paketo-buildpacks/packit/v2/sbom"
@@ -130,19 +134,32 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		})
 		Expect(err).NotTo(HaveOccurred())
 
-		Expect(result.Layers).To(HaveLen(1))
+		Expect(result.Layers).To(HaveLen(3))
 		layer := result.Layers[0]
 
 		Expect(layer.Name).To(Equal("dotnet-core-runtime"))
 		Expect(layer.Path).To(Equal(filepath.Join(layersDir, "dotnet-core-runtime")))
 		Expect(layer.LaunchEnv).To(Equal(packit.Environment{
-			"DOTNET_ROOT.override": filepath.Join(workingDir, ".dotnet_root"),
+			"DOTNET_ROOT.override":                 filepath.Join(workingDir, ".dotnet_root"),
+			"BPI_DOTNET_RUNTIME_VERSION.override":  "2.5.x",
+			"BPI_DOTNET_RUNTIME_MANIFEST.override": filepath.Join(layersDir, "dotnet-core-runtime", "runtime-manifest.json"),
 		}))
 		Expect(layer.BuildEnv).To(Equal(packit.Environment{
 			"RUNTIME_VERSION.override": "2.5.x",
 		}))
 		Expect(layer.Metadata).To(Equal(map[string]interface{}{
-			"dependency-sha": "some-sha",
+			"dependency-checksum": "some-sha",
+		}))
+		Expect(layer.ExecD).To(Equal([]string{
+			filepath.Join(cnbDir, "bin", "verify-runtime"),
+			filepath.Join(cnbDir, "bin", "configure-diagnostics"),
+		}))
+
+		runtimeManifest, err := manifest.Read(filepath.Join(layersDir, "dotnet-core-runtime", "runtime-manifest.json"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runtimeManifest).To(Equal(manifest.Manifest{
+			Version: "2.5.x",
+			Files:   map[string]manifest.File{},
 		}))
 
 		Expect(layer.Build).To(BeFalse())
@@ -160,6 +177,47 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			},
 		}))
 
+		reportLayer := result.Layers[1]
+		Expect(reportLayer.Name).To(Equal("dotnet-core-runtime-report"))
+		Expect(reportLayer.Launch).To(BeFalse())
+		Expect(reportLayer.Build).To(BeTrue())
+		Expect(reportLayer.Cache).To(BeFalse())
+
+		downloadsLayer := result.Layers[2]
+		Expect(downloadsLayer.Name).To(Equal("dotnet-core-runtime-downloads"))
+		Expect(downloadsLayer.Path).To(BeADirectory())
+		Expect(downloadsLayer.Launch).To(BeFalse())
+		Expect(downloadsLayer.Build).To(BeFalse())
+		Expect(downloadsLayer.Cache).To(BeTrue())
+
+		content, err := os.ReadFile(filepath.Join(layersDir, "dotnet-core-runtime-report", "report.json"))
+		Expect(err).NotTo(HaveOccurred())
+
+		var report dotnetcoreruntime.RuntimeReport
+		Expect(json.Unmarshal(content, &report)).To(Succeed())
+		Expect(report.InstallDuration).To(BeNumerically(">=", 0))
+		Expect(report.SBOMDuration).To(BeNumerically(">=", 0))
+		report.InstallDuration, report.SBOMDuration = 0, 0
+		Expect(report).To(Equal(dotnetcoreruntime.RuntimeReport{
+			VersionSource:    "BP_DOTNET_FRAMEWORK_VERSION",
+			RequestedVersion: "2.5.x",
+			Dependency: dotnetcoreruntime.RuntimeReportDependency{
+				ID:      "dotnet-runtime",
+				Name:    ".NET Core Runtime",
+				Version: "2.5.x",
+				SHA256:  "some-sha",
+			},
+			Launch: true,
+			LaunchEnv: packit.Environment{
+				"DOTNET_ROOT.override":                 filepath.Join(workingDir, ".dotnet_root"),
+				"BPI_DOTNET_RUNTIME_VERSION.override":  "2.5.x",
+				"BPI_DOTNET_RUNTIME_MANIFEST.override": filepath.Join(layersDir, "dotnet-core-runtime", "runtime-manifest.json"),
+			},
+			BuildEnv: packit.Environment{
+				"RUNTIME_VERSION.override": "2.5.x",
+			},
+		}))
+
 		Expect(result.Launch.BOM).To(HaveLen(1))
 		launchBOMEntry := result.Launch.BOM[0]
 		Expect(launchBOMEntry.Name).To(Equal("dotnet-runtime"))
@@ -195,6 +253,7 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		Expect(versionResolver.ResolveCall.Receives.Path).To(Equal(filepath.Join(cnbDir, "buildpack.toml")))
 		Expect(versionResolver.ResolveCall.Receives.Entry).To(Equal(entryResolver.ResolveCall.Returns.BuildpackPlanEntry))
 		Expect(versionResolver.ResolveCall.Receives.Stack).To(Equal("some-stack"))
+		Expect(versionResolver.ResolveCall.Receives.PlatformDir).To(Equal("platform"))
 
 		Expect(dependencyManager.DeliverCall.Receives.Dependency).To(Equal(postal.Dependency{
 			ID:      "dotnet-runtime",
@@ -206,36 +265,1156 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		Expect(dependencyManager.DeliverCall.Receives.LayerPath).To(Equal(filepath.Join(layersDir, "dotnet-core-runtime")))
 		Expect(dependencyManager.DeliverCall.Receives.PlatformPath).To(Equal("platform"))
 
-		Expect(dotnetSymlinker.LinkCall.CallCount).To(Equal(1))
-		Expect(dotnetSymlinker.LinkCall.Receives.WorkingDir).To(Equal(workingDir))
-		Expect(dotnetSymlinker.LinkCall.Receives.LayerPath).To(Equal(filepath.Join(layersDir, "dotnet-core-runtime")))
+		Expect(dotnetSymlinker.LinkCall.CallCount).To(Equal(1))
+		Expect(dotnetSymlinker.LinkCall.Receives.DotnetRoot).To(Equal(filepath.Join(workingDir, ".dotnet_root")))
+		Expect(dotnetSymlinker.LinkCall.Receives.LayerPath).To(Equal(filepath.Join(layersDir, "dotnet-core-runtime")))
+
+		Expect(sbomGenerator.GenerateFromDependencyCall.Receives.Dependency).To(Equal(postal.Dependency{
+			ID:      "dotnet-runtime",
+			Version: "2.5.x",
+			Name:    ".NET Core Runtime",
+			SHA256:  "some-sha", //nolint:staticcheck
+		}))
+		Expect(sbomGenerator.GenerateFromDependencyCall.Receives.Dir).To(Equal(filepath.Join(layersDir, "dotnet-core-runtime")))
+
+		Expect(buffer.String()).To(ContainSubstring("Some Buildpack some-version"))
+		Expect(buffer.String()).To(ContainSubstring("Resolving .NET Core Runtime version"))
+		Expect(buffer.String()).To(ContainSubstring("Selected .NET Core Runtime version (using BP_DOTNET_FRAMEWORK_VERSION): "))
+		Expect(buffer.String()).To(ContainSubstring("Executing build process"))
+		Expect(buffer.String()).To(ContainSubstring("Configuring build environment"))
+		Expect(buffer.String()).To(ContainSubstring("Configuring launch environment"))
+	})
+
+	context("when there is a dependency cache match", func() {
+		it.Before(func() {
+			entryResolver.MergeLayerTypesCall.Returns.Build = true
+			entryResolver.MergeLayerTypesCall.Returns.Launch = false
+
+			err := os.WriteFile(filepath.Join(layersDir, "dotnet-core-runtime.toml"), []byte("[metadata]\ndependency-checksum = \"some-sha\"\n"), 0600)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		it("returns a result that installs the dotnet runtime libraries", func() {
+			result, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "some-version",
+				},
+				Plan: packit.BuildpackPlan{
+					Entries: []packit.BuildpackPlanEntry{
+						{
+							Name: "dotnet-runtime",
+							Metadata: map[string]interface{}{
+								"version-source": "BP_DOTNET_FRAMEWORK_VERSION",
+								"version":        "2.5.x",
+								"launch":         true,
+							},
+						},
+					},
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(entryResolver.ResolveCall.Receives.BuildpackPlanEntrySlice).To(Equal([]packit.BuildpackPlanEntry{
+				{
+					Name: "dotnet-runtime",
+					Metadata: map[string]interface{}{
+						"version-source": "BP_DOTNET_FRAMEWORK_VERSION",
+						"version":        "2.5.x",
+						"launch":         true,
+					},
+				},
+			}))
+
+			Expect(dependencyManager.GenerateBillOfMaterialsCall.Receives.Dependencies).To(Equal([]postal.Dependency{
+				{
+					ID:      "dotnet-runtime",
+					Version: "2.5.x",
+					Name:    ".NET Core Runtime",
+					SHA256:  "some-sha", //nolint:staticcheck
+				},
+			}))
+
+			Expect(versionResolver.ResolveCall.Receives.Path).To(Equal(filepath.Join(cnbDir, "buildpack.toml")))
+			Expect(versionResolver.ResolveCall.Receives.Entry).To(Equal(entryResolver.ResolveCall.Returns.BuildpackPlanEntry))
+			Expect(versionResolver.ResolveCall.Receives.Stack).To(Equal("some-stack"))
+
+			Expect(dependencyManager.DeliverCall.CallCount).To(Equal(0))
+
+			Expect(dotnetSymlinker.LinkCall.CallCount).To(Equal(1))
+			Expect(dotnetSymlinker.LinkCall.Receives.DotnetRoot).To(Equal(filepath.Join(workingDir, ".dotnet_root")))
+			Expect(dotnetSymlinker.LinkCall.Receives.LayerPath).To(Equal(filepath.Join(layersDir, "dotnet-core-runtime")))
+
+			Expect(buffer.String()).To(ContainSubstring("Some Buildpack some-version"))
+			Expect(buffer.String()).To(ContainSubstring("Resolving .NET Core Runtime version"))
+			Expect(buffer.String()).To(ContainSubstring("Selected .NET Core Runtime version (using BP_DOTNET_FRAMEWORK_VERSION): "))
+			Expect(buffer.String()).To(ContainSubstring("Reusing cached layer"))
+			Expect(buffer.String()).NotTo(ContainSubstring("Executing build process"))
+
+			Expect(result.Layers).To(HaveLen(2))
+			Expect(result.Layers[0].ExecD).To(Equal([]string{
+				filepath.Join(cnbDir, "bin", "verify-runtime"),
+				filepath.Join(cnbDir, "bin", "configure-diagnostics"),
+			}))
+			Expect(result.Layers[1].Name).To(Equal("dotnet-core-runtime-report"))
+
+			content, err := os.ReadFile(filepath.Join(layersDir, "dotnet-core-runtime-report", "report.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring(`"cache-reused": true`))
+		})
+	})
+
+	context("when the dependencies only carry a checksum", func() {
+		var buildContext packit.BuildContext
+
+		it.Before(func() {
+			versionResolver.ResolveCall.Returns.Dependency = postal.Dependency{
+				ID:       "dotnet-runtime",
+				Version:  "2.5.x",
+				Name:     ".NET Core Runtime",
+				Checksum: "sha256:some-checksum",
+			}
+
+			err := os.WriteFile(filepath.Join(layersDir, "dotnet-core-runtime.toml"), []byte("[metadata]\ndependency-checksum = \"sha256:some-checksum\"\n"), 0600)
+			Expect(err).NotTo(HaveOccurred())
+
+			buildContext = packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "some-version",
+				},
+				Plan: packit.BuildpackPlan{
+					Entries: []packit.BuildpackPlanEntry{
+						{
+							Name: "dotnet-runtime",
+							Metadata: map[string]interface{}{
+								"version-source": "BP_DOTNET_FRAMEWORK_VERSION",
+								"version":        "2.5.x",
+								"launch":         true,
+							},
+						},
+					},
+				},
+				Layers: packit.Layers{Path: layersDir},
+			}
+		})
+
+		it("reuses the layer installed from the same checksum", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(dependencyManager.DeliverCall.CallCount).To(Equal(0))
+			Expect(buffer.String()).To(ContainSubstring("Reusing cached layer"))
+		})
+
+		context("when the selected dependency has another checksum", func() {
+			it.Before(func() {
+				versionResolver.ResolveCall.Returns.Dependency.Checksum = "sha256:other-checksum"
+			})
+
+			it("reinstalls the runtime and records the new checksum", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(dependencyManager.DeliverCall.CallCount).To(Equal(1))
+				Expect(result.Layers[0].Metadata).To(Equal(map[string]interface{}{
+					"dependency-checksum": "sha256:other-checksum",
+				}))
+			})
+		})
+
+		context("when the layer records the SHA256 of the dependency as dependency-sha", func() {
+			it.Before(func() {
+				err := os.WriteFile(filepath.Join(layersDir, "dotnet-core-runtime.toml"), []byte("[metadata]\ndependency-sha = \"some-checksum\"\n"), 0600)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			it("reuses the layer", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(dependencyManager.DeliverCall.CallCount).To(Equal(0))
+				Expect(buffer.String()).To(ContainSubstring("Reusing cached layer"))
+			})
+		})
+	})
+
+	context("when BP_DOTNET_ROOT is set", func() {
+		var buildContext packit.BuildContext
+
+		it.Before(func() {
+			buildContext = packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "some-version",
+				},
+				Plan: packit.BuildpackPlan{
+					Entries: []packit.BuildpackPlanEntry{
+						{
+							Name: "dotnet-runtime",
+							Metadata: map[string]interface{}{
+								"version-source": "BP_DOTNET_FRAMEWORK_VERSION",
+								"version":        "2.5.x",
+								"launch":         true,
+							},
+						},
+					},
+				},
+				Layers: packit.Layers{Path: layersDir},
+			}
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_DOTNET_ROOT")).To(Succeed())
+		})
+
+		context("to layer", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_DOTNET_ROOT", "layer")).To(Succeed())
+			})
+
+			it("points DOTNET_ROOT at the runtime layer without creating links", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				layer := result.Layers[0]
+				Expect(layer.LaunchEnv).To(HaveKeyWithValue("DOTNET_ROOT.override", filepath.Join(layersDir, "dotnet-core-runtime")))
+				Expect(layer.Metadata).To(HaveKeyWithValue("dotnet-root", filepath.Join(layersDir, "dotnet-core-runtime")))
+				Expect(dotnetSymlinker.LinkCall.CallCount).To(Equal(0))
+			})
+		})
+
+		context("to a path relative to the working directory", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_DOTNET_ROOT", "runtime/root")).To(Succeed())
+			})
+
+			it("builds the symlink tree there", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				layer := result.Layers[0]
+				Expect(layer.LaunchEnv).To(HaveKeyWithValue("DOTNET_ROOT.override", filepath.Join(workingDir, "runtime", "root")))
+				Expect(dotnetSymlinker.LinkCall.Receives.DotnetRoot).To(Equal(filepath.Join(workingDir, "runtime", "root")))
+				Expect(dotnetSymlinker.LinkCall.Receives.LayerPath).To(Equal(filepath.Join(layersDir, "dotnet-core-runtime")))
+			})
+
+			context("when the cached layer used the default DOTNET_ROOT", func() {
+				it.Before(func() {
+					err := os.WriteFile(filepath.Join(layersDir, "dotnet-core-runtime.toml"), []byte("[metadata]\ndependency-checksum = \"some-sha\"\n"), 0600)
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				it("reinstalls the runtime", func() {
+					_, err := build(buildContext)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(dependencyManager.DeliverCall.CallCount).To(Equal(1))
+				})
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the path is outside of the working directory", func() {
+				it.Before(func() {
+					Expect(os.Setenv("BP_DOTNET_ROOT", "/opt/dotnet")).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError(fmt.Sprintf(`invalid BP_DOTNET_ROOT value "/opt/dotnet": must be "layer" or a directory inside the application directory %s`, workingDir)))
+				})
+			})
+		})
+	})
+
+	context("when BP_DOTNET_RUNTIME_DISABLE_DIAGNOSTICS is true", func() {
+		var buildContext packit.BuildContext
+
+		it.Before(func() {
+			Expect(os.Setenv("BP_DOTNET_RUNTIME_DISABLE_DIAGNOSTICS", "true")).To(Succeed())
+
+			buildContext = packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "some-version",
+				},
+				Plan: packit.BuildpackPlan{
+					Entries: []packit.BuildpackPlanEntry{
+						{
+							Name: "dotnet-runtime",
+							Metadata: map[string]interface{}{
+								"version-source": "BP_DOTNET_FRAMEWORK_VERSION",
+								"version":        "2.5.x",
+								"launch":         true,
+							},
+						},
+					},
+				},
+				Layers: packit.Layers{Path: layersDir},
+			}
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_DOTNET_RUNTIME_DISABLE_DIAGNOSTICS")).To(Succeed())
+		})
+
+		it("disables the diagnostics IPC channel by default at launch", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			layer := result.Layers[0]
+			Expect(layer.LaunchEnv).To(HaveKeyWithValue("DOTNET_EnableDiagnostics.default", "0"))
+			Expect(layer.Metadata).To(HaveKeyWithValue("diagnostics-disabled", true))
+		})
+
+		context("when the cached layer did not disable diagnostics", func() {
+			it.Before(func() {
+				err := os.WriteFile(filepath.Join(layersDir, "dotnet-core-runtime.toml"), []byte("[metadata]\ndependency-checksum = \"some-sha\"\n"), 0600)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			it("reinstalls the runtime", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(dependencyManager.DeliverCall.CallCount).To(Equal(1))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when BP_DOTNET_RUNTIME_DISABLE_DIAGNOSTICS is not a boolean", func() {
+				it.Before(func() {
+					Expect(os.Setenv("BP_DOTNET_RUNTIME_DISABLE_DIAGNOSTICS", "some-bad-value")).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError(ContainSubstring(`failed to parse BP_DOTNET_RUNTIME_DISABLE_DIAGNOSTICS value "some-bad-value"`)))
+				})
+			})
+		})
+	})
+
+	context("when BP_DOTNET_RUNTIME_VERIFY_HASHES is true", func() {
+		var buildContext packit.BuildContext
+
+		it.Before(func() {
+			Expect(os.Setenv("BP_DOTNET_RUNTIME_VERIFY_HASHES", "true")).To(Succeed())
+
+			dependencyManager.DeliverCall.Stub = func(_ postal.Dependency, _, layerPath, _ string) error {
+				return os.WriteFile(filepath.Join(layerPath, "dotnet"), []byte("dotnet-host"), 0755)
+			}
+
+			buildContext = packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "some-version",
+				},
+				Plan: packit.BuildpackPlan{
+					Entries: []packit.BuildpackPlanEntry{
+						{
+							Name: "dotnet-runtime",
+							Metadata: map[string]interface{}{
+								"version-source": "BP_DOTNET_FRAMEWORK_VERSION",
+								"version":        "2.5.x",
+								"launch":         true,
+							},
+						},
+					},
+				},
+				Layers: packit.Layers{Path: layersDir},
+			}
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_DOTNET_RUNTIME_VERIFY_HASHES")).To(Succeed())
+		})
+
+		it("records the checksums of the runtime files and verifies them by default at launch", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			layer := result.Layers[0]
+			Expect(layer.LaunchEnv).To(HaveKeyWithValue("BPL_DOTNET_RUNTIME_VERIFY_HASHES.default", "true"))
+			Expect(layer.Metadata).To(HaveKeyWithValue("manifest-hashes", true))
+
+			runtimeManifest, err := manifest.Read(filepath.Join(layer.Path, "runtime-manifest.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runtimeManifest.Files["dotnet"].Size).To(Equal(int64(11)))
+			Expect(runtimeManifest.Files["dotnet"].SHA256).NotTo(BeEmpty())
+		})
+
+		context("when the cached layer did not record checksums", func() {
+			it.Before(func() {
+				err := os.WriteFile(filepath.Join(layersDir, "dotnet-core-runtime.toml"), []byte("[metadata]\ndependency-checksum = \"some-sha\"\n"), 0600)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			it("reinstalls the runtime", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(dependencyManager.DeliverCall.CallCount).To(Equal(1))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when BP_DOTNET_RUNTIME_VERIFY_HASHES is not a boolean", func() {
+				it.Before(func() {
+					Expect(os.Setenv("BP_DOTNET_RUNTIME_VERIFY_HASHES", "some-bad-value")).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError(ContainSubstring(`failed to parse BP_DOTNET_RUNTIME_VERIFY_HASHES value "some-bad-value"`)))
+				})
+			})
+		})
+	})
+
+	context("when BP_DOTNET_RUNTIME_TRIM is true", func() {
+		var buildContext packit.BuildContext
+
+		it.Before(func() {
+			Expect(os.Setenv("BP_DOTNET_RUNTIME_TRIM", "true")).To(Succeed())
+
+			dependencyManager.DeliverCall.Stub = func(_ postal.Dependency, _, layerPath, _ string) error {
+				for _, file := range []string{"dotnet", "shared/Microsoft.NETCore.App/2.5.0/createdump", "shared/Microsoft.NETCore.App/2.5.0/libcoreclr.so"} {
+					err := os.MkdirAll(filepath.Dir(filepath.Join(layerPath, file)), os.ModePerm)
+					if err != nil {
+						return err
+					}
+
+					err = os.WriteFile(filepath.Join(layerPath, file), nil, 0755)
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			buildContext = packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "some-version",
+				},
+				Plan: packit.BuildpackPlan{
+					Entries: []packit.BuildpackPlanEntry{
+						{
+							Name: "dotnet-runtime",
+							Metadata: map[string]interface{}{
+								"version-source": "BP_DOTNET_FRAMEWORK_VERSION",
+								"version":        "2.5.x",
+								"launch":         true,
+							},
+						},
+					},
+				},
+				Layers: packit.Layers{Path: layersDir},
+			}
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_DOTNET_RUNTIME_TRIM")).To(Succeed())
+		})
+
+		it("removes the default trim patterns from the layer and records what was removed", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			layer := result.Layers[0]
+			Expect(layer.Metadata).To(Equal(map[string]interface{}{
+				"dependency-checksum": "some-sha",
+				"trim-patterns":       "createdump,*.dbg,*.pdb,libmscordbi.so,libmscordaccore.so,libcoreclrtraceptprovider.so",
+				"trimmed-files":       []string{"shared/Microsoft.NETCore.App/2.5.0/createdump"},
+			}))
+
+			Expect(filepath.Join(layer.Path, "shared", "Microsoft.NETCore.App", "2.5.0", "createdump")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(layer.Path, "shared", "Microsoft.NETCore.App", "2.5.0", "libcoreclr.so")).To(BeARegularFile())
+
+			runtimeManifest, err := manifest.Read(filepath.Join(layer.Path, "runtime-manifest.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runtimeManifest.Files).NotTo(HaveKey("shared/Microsoft.NETCore.App/2.5.0/createdump"))
+
+			Expect(sbomGenerator.GenerateFromDependencyCall.Receives.Dir).To(Equal(filepath.Join(layersDir, "dotnet-core-runtime")))
+
+			content, err := os.ReadFile(filepath.Join(layersDir, "dotnet-core-runtime-report", "report.json"))
+			Expect(err).NotTo(HaveOccurred())
+
+			var report dotnetcoreruntime.RuntimeReport
+			Expect(json.Unmarshal(content, &report)).To(Succeed())
+			Expect(report.TrimmedFiles).To(Equal([]string{"shared/Microsoft.NETCore.App/2.5.0/createdump"}))
+
+			Expect(buffer.String()).To(ContainSubstring("Trimming .NET Core Runtime (createdump,*.dbg,*.pdb,libmscordbi.so,libmscordaccore.so,libcoreclrtraceptprovider.so)"))
+			Expect(buffer.String()).To(ContainSubstring("Removed shared/Microsoft.NETCore.App/2.5.0/createdump"))
+		})
+
+		context("when BP_DOTNET_RUNTIME_TRIM_PATTERNS is set", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_DOTNET_RUNTIME_TRIM_PATTERNS", "shared/*/*/libcoreclr.so, dotnet")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_DOTNET_RUNTIME_TRIM_PATTERNS")).To(Succeed())
+			})
+
+			it("removes the files matching the given patterns instead", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				layer := result.Layers[0]
+				Expect(layer.Metadata["trim-patterns"]).To(Equal("shared/*/*/libcoreclr.so,dotnet"))
+				Expect(layer.Metadata["trimmed-files"]).To(Equal([]string{"dotnet", "shared/Microsoft.NETCore.App/2.5.0/libcoreclr.so"}))
+				Expect(filepath.Join(layer.Path, "shared", "Microsoft.NETCore.App", "2.5.0", "createdump")).To(BeARegularFile())
+			})
+		})
+
+		context("when the cached layer was trimmed the same way", func() {
+			it.Before(func() {
+				err := os.WriteFile(filepath.Join(layersDir, "dotnet-core-runtime.toml"), []byte(`[metadata]
+dependency-checksum = "some-sha"
+trim-patterns = "createdump,*.dbg,*.pdb,libmscordbi.so,libmscordaccore.so,libcoreclrtraceptprovider.so"
+trimmed-files = ["shared/Microsoft.NETCore.App/2.5.0/createdump"]
+`), 0600)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			it("reuses the layer", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(dependencyManager.DeliverCall.CallCount).To(Equal(0))
+
+				content, err := os.ReadFile(filepath.Join(layersDir, "dotnet-core-runtime-report", "report.json"))
+				Expect(err).NotTo(HaveOccurred())
+
+				var report dotnetcoreruntime.RuntimeReport
+				Expect(json.Unmarshal(content, &report)).To(Succeed())
+				Expect(report.CacheReused).To(BeTrue())
+				Expect(report.TrimmedFiles).To(Equal([]string{"shared/Microsoft.NETCore.App/2.5.0/createdump"}))
+			})
+		})
+
+		context("when the cached layer was not trimmed", func() {
+			it.Before(func() {
+				err := os.WriteFile(filepath.Join(layersDir, "dotnet-core-runtime.toml"), []byte("[metadata]\ndependency-checksum = \"some-sha\"\n"), 0600)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			it("reinstalls the runtime", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(dependencyManager.DeliverCall.CallCount).To(Equal(1))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when BP_DOTNET_RUNTIME_TRIM is not a boolean", func() {
+				it.Before(func() {
+					Expect(os.Setenv("BP_DOTNET_RUNTIME_TRIM", "some-bad-value")).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError(ContainSubstring(`failed to parse BP_DOTNET_RUNTIME_TRIM value "some-bad-value"`)))
+				})
+			})
+
+			context("when BP_DOTNET_RUNTIME_TRIM_PATTERNS contains an invalid pattern", func() {
+				it.Before(func() {
+					Expect(os.Setenv("BP_DOTNET_RUNTIME_TRIM_PATTERNS", "[")).To(Succeed())
+				})
+
+				it.After(func() {
+					Expect(os.Unsetenv("BP_DOTNET_RUNTIME_TRIM_PATTERNS")).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError(ContainSubstring(`invalid BP_DOTNET_RUNTIME_TRIM_PATTERNS pattern "["`)))
+				})
+			})
+		})
+	})
+
+	context("when version-source of the selected entry is buildpack.yml", func() {
+		it.Before(func() {
+			entryResolver.ResolveCall.Returns.BuildpackPlanEntry = packit.BuildpackPlanEntry{
+				Name: "dotnet-runtime",
+				Metadata: map[string]interface{}{
+					"version-source": "buildpack.yml",
+					"version":        "2.5.x",
+					"launch":         true,
+				},
+			}
+		})
+
+		it("prints a deprecation warning", func() {
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "0.1.2",
+				},
+				Plan: packit.BuildpackPlan{
+					Entries: []packit.BuildpackPlanEntry{
+						{
+							Name: "dotnet-runtime",
+							Metadata: map[string]interface{}{
+								"version-source": "buildpack.yml",
+								"version":        "2.5.x",
+								"launch":         true,
+							},
+						},
+					},
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buffer.String()).To(ContainSubstring("Some Buildpack 0.1.2"))
+			Expect(buffer.String()).To(ContainSubstring("Resolving .NET Core Runtime version"))
+			Expect(buffer.String()).To(ContainSubstring("Selected .NET Core Runtime version (using buildpack.yml): "))
+			// v1.0.0 because that's the next major after input version v0.1.2
+			Expect(buffer.String()).To(ContainSubstring("WARNING: Setting the .NET Framework version through buildpack.yml will be deprecated soon in .NET Core Runtime Buildpack v1.0.0."))
+			Expect(buffer.String()).To(ContainSubstring("Please specify the version through the $BP_DOTNET_FRAMEWORK_VERSION environment variable instead. See docs for more information."))
+			Expect(buffer.String()).To(ContainSubstring("Executing build process"))
+			Expect(buffer.String()).To(ContainSubstring("Configuring build environment"))
+			Expect(buffer.String()).To(ContainSubstring("Configuring launch environment"))
+		})
+	})
+
+	context("when the application's runtimeconfig.json requires a newer runtime", func() {
+		var buildContext packit.BuildContext
+
+		it.Before(func() {
+			versionResolver.ResolveCall.Returns.Dependency.Version = "6.0.13"
+
+			Expect(os.WriteFile(filepath.Join(workingDir, "app.runtimeconfig.json"), []byte(`{
+				"runtimeOptions": {
+					"framework": {
+						"name": "Microsoft.NETCore.App",
+						"version": "7.0.0"
+					}
+				}
+			}`), 0600)).To(Succeed())
+
+			buildContext = packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "some-version",
+				},
+				Plan: packit.BuildpackPlan{
+					Entries: []packit.BuildpackPlanEntry{
+						{
+							Name: "dotnet-runtime",
+							Metadata: map[string]interface{}{
+								"version-source": "BP_DOTNET_FRAMEWORK_VERSION",
+								"version":        "2.5.x",
+								"launch":         true,
+							},
+						},
+					},
+				},
+				Layers: packit.Layers{Path: layersDir},
+			}
+		})
+
+		it("warns about the mismatch and installs the runtime", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buffer.String()).To(ContainSubstring("WARNING: app.runtimeconfig.json requires Microsoft.NETCore.App 7.0.0 (rollForward: Minor), which .NET Core Runtime 6.0.13 does not satisfy"))
+			Expect(dependencyManager.DeliverCall.CallCount).To(Equal(1))
+		})
+
+		context("when the .deps.json targets a different runtime", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "app.deps.json"), []byte(`{
+					"runtimeTarget": {"name": ".NETCoreApp,Version=v6.0"}
+				}`), 0600)).To(Succeed())
+			})
+
+			it("warns about the disagreement", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(buffer.String()).To(ContainSubstring("WARNING: app.runtimeconfig.json targets Microsoft.NETCore.App 7.0.0, but app.deps.json targets 6.0.0"))
+			})
+		})
+
+		context("when the .deps.json is malformed", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "app.deps.json"), []byte("%%%"), 0600)).To(Succeed())
+			})
+
+			it("warns that it cannot be compared and installs the runtime", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(buffer.String()).To(ContainSubstring("WARNING: failed to compare .deps.json with runtimeconfig.json: failed to parse " + filepath.Join(workingDir, "app.deps.json")))
+				Expect(dependencyManager.DeliverCall.CallCount).To(Equal(1))
+			})
+		})
+
+		context("when BP_DOTNET_PROJECT_PATH selects a project directory", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(workingDir, "src", "app"), os.ModePerm)).To(Succeed())
+				Expect(os.Rename(filepath.Join(workingDir, "app.runtimeconfig.json"), filepath.Join(workingDir, "src", "app", "app.runtimeconfig.json"))).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "src", "app", "app.deps.json"), []byte(`{
+					"runtimeTarget": {"name": ".NETCoreApp,Version=v6.0"}
+				}`), 0600)).To(Succeed())
+				Expect(os.Setenv("BP_DOTNET_PROJECT_PATH", "src/app")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_DOTNET_PROJECT_PATH")).To(Succeed())
+			})
+
+			it("checks the runtimeconfig.json and .deps.json in the project directory", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(buffer.String()).To(ContainSubstring("WARNING: app.runtimeconfig.json requires Microsoft.NETCore.App 7.0.0 (rollForward: Minor), which .NET Core Runtime 6.0.13 does not satisfy"))
+				Expect(buffer.String()).To(ContainSubstring("WARNING: app.runtimeconfig.json targets Microsoft.NETCore.App 7.0.0, but app.deps.json targets 6.0.0"))
+			})
+		})
+
+		context("when BP_DOTNET_RUNTIME_CONFIG_CHECK is fail", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_DOTNET_RUNTIME_CONFIG_CHECK", "fail")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_DOTNET_RUNTIME_CONFIG_CHECK")).To(Succeed())
+			})
+
+			it("fails the build with the mismatch", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(ContainSubstring("app.runtimeconfig.json requires Microsoft.NETCore.App 7.0.0 (rollForward: Minor), which .NET Core Runtime 6.0.13 does not satisfy")))
+				Expect(err).To(MatchError(ContainSubstring("BP_DOTNET_RUNTIME_CONFIG_CHECK=warn")))
+				Expect(dependencyManager.DeliverCall.CallCount).To(Equal(0))
+			})
+		})
+
+		context("when the runtimeconfig.json is malformed", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "app.runtimeconfig.json"), []byte("%%%"), 0600)).To(Succeed())
+			})
+
+			it("warns that it cannot be checked and installs the runtime", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(buffer.String()).To(ContainSubstring("WARNING: app.runtimeconfig.json cannot be checked against .NET Core Runtime 6.0.13: failed to parse"))
+				Expect(dependencyManager.DeliverCall.CallCount).To(Equal(1))
+			})
+
+			context("when BP_DOTNET_RUNTIME_CONFIG_CHECK is fail", func() {
+				it.Before(func() {
+					Expect(os.Setenv("BP_DOTNET_RUNTIME_CONFIG_CHECK", "fail")).To(Succeed())
+				})
+
+				it.After(func() {
+					Expect(os.Unsetenv("BP_DOTNET_RUNTIME_CONFIG_CHECK")).To(Succeed())
+				})
+
+				it("fails the build", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError(ContainSubstring("app.runtimeconfig.json cannot be checked against .NET Core Runtime 6.0.13: failed to parse")))
+					Expect(dependencyManager.DeliverCall.CallCount).To(Equal(0))
+				})
+			})
+		})
+
+		context("when BP_DOTNET_RUNTIME_CONFIG_CHECK is off", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_DOTNET_RUNTIME_CONFIG_CHECK", "off")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_DOTNET_RUNTIME_CONFIG_CHECK")).To(Succeed())
+			})
+
+			it("does not check the runtimeconfig.json", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(buffer.String()).NotTo(ContainSubstring("app.runtimeconfig.json"))
+				Expect(dependencyManager.DeliverCall.CallCount).To(Equal(1))
+			})
+
+			context("when the .deps.json targets a different runtime", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "app.deps.json"), []byte(`{
+						"runtimeTarget": {"name": ".NETCoreApp,Version=v6.0"}
+					}`), 0600)).To(Succeed())
+				})
+
+				it("still warns about the disagreement", func() {
+					_, err := build(buildContext)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(buffer.String()).To(ContainSubstring("WARNING: app.runtimeconfig.json targets Microsoft.NETCore.App 7.0.0, but app.deps.json targets 6.0.0"))
+					Expect(buffer.String()).NotTo(ContainSubstring("which .NET Core Runtime 6.0.13 does not satisfy"))
+				})
+			})
+		})
+
+		context("when BP_DOTNET_RUNTIME_CONFIG_CHECK is not a valid mode", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_DOTNET_RUNTIME_CONFIG_CHECK", "sometimes")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_DOTNET_RUNTIME_CONFIG_CHECK")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(`invalid BP_DOTNET_RUNTIME_CONFIG_CHECK value "sometimes": must be one of fail, warn or off`))
+			})
+		})
+	})
+
+	context("when the selected version was rolled forward", func() {
+		it.Before(func() {
+			entryResolver.ResolveCall.Returns.BuildpackPlanEntry = packit.BuildpackPlanEntry{
+				Name: "dotnet-runtime",
+				Metadata: map[string]interface{}{
+					"version-source": "runtimeconfig.json",
+					"version":        "6.0.0",
+					"launch":         true,
+				},
+			}
+
+			versionResolver.ResolveCall.Returns.Dependency = postal.Dependency{
+				ID:      "dotnet-runtime",
+				Version: "6.0.13",
+				Name:    ".NET Core Runtime",
+				SHA256:  "some-sha", //nolint:staticcheck
+			}
+		})
+
+		it("records the roll-forward steps in the runtime report", func() {
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "some-version",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			content, err := os.ReadFile(filepath.Join(layersDir, "dotnet-core-runtime-report", "report.json"))
+			Expect(err).NotTo(HaveOccurred())
+
+			var report dotnetcoreruntime.RuntimeReport
+			Expect(json.Unmarshal(content, &report)).To(Succeed())
+			Expect(report.RequestedVersion).To(Equal("6.0.0"))
+			Expect(report.RollForward).To(Equal([]string{"6.0.0", "6.0.*"}))
+		})
+	})
+
+	context("when the application is self-contained and nothing requires the runtime", func() {
+		it.Before(func() {
+			entryResolver.MergeLayerTypesCall.Returns.Launch = false
+			entryResolver.MergeLayerTypesCall.Returns.Build = false
+			versionResolver.ResolveCall.Returns.Dependency.Version = "6.0.13"
+
+			Expect(os.WriteFile(filepath.Join(workingDir, "app.runtimeconfig.json"), []byte(`{
+  "runtimeOptions": {
+    "includedFrameworks": [{"name": "Microsoft.NETCore.App", "version": "6.0.13"}]
+  }
+}`), 0600)).To(Succeed())
+
+			Expect(os.MkdirAll(filepath.Join(layersDir, "dotnet-core-runtime", "shared"), os.ModePerm)).To(Succeed())
+		})
+
+		it("skips the installation and clears the runtime layer", func() {
+			result, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "some-version",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(HaveLen(2))
+			layer := result.Layers[0]
+			Expect(layer.Name).To(Equal("dotnet-core-runtime"))
+			Expect(layer.Launch).To(BeFalse())
+			Expect(layer.Build).To(BeFalse())
+			Expect(layer.Cache).To(BeFalse())
+			Expect(filepath.Join(layersDir, "dotnet-core-runtime", "shared")).NotTo(BeADirectory())
+
+			Expect(versionResolver.ResolveCall.CallCount).To(Equal(0))
+			Expect(dependencyManager.DeliverCall.CallCount).To(Equal(0))
+
+			Expect(buffer.String()).To(ContainSubstring("Skipping installation of .NET Core Runtime: the application is self-contained"))
+			Expect(buffer.String()).To(ContainSubstring("Set BP_DOTNET_RUNTIME_FORCE_INSTALL=true to install it anyway"))
+
+			content, err := os.ReadFile(filepath.Join(result.Layers[1].Path, "report.json"))
+			Expect(err).NotTo(HaveOccurred())
+
+			var report dotnetcoreruntime.RuntimeReport
+			Expect(json.Unmarshal(content, &report)).To(Succeed())
+			Expect(report.SelfContained).To(BeTrue())
+			Expect(report.VersionSource).To(Equal("BP_DOTNET_FRAMEWORK_VERSION"))
+		})
+
+		context("when the download cache holds runtime tarballs", func() {
+			it.Before(func() {
+				downloadsDir := filepath.Join(layersDir, "dotnet-core-runtime-downloads")
+				Expect(os.MkdirAll(downloadsDir, os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(downloadsDir, "some-sha"), []byte("some-tarball"), 0600)).To(Succeed())
+			})
+
+			it("keeps the download cache for the next build", func() {
+				result, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Layers).To(HaveLen(3))
+				downloadsLayer := result.Layers[2]
+				Expect(downloadsLayer.Name).To(Equal("dotnet-core-runtime-downloads"))
+				Expect(downloadsLayer.Cache).To(BeTrue())
+				Expect(downloadsLayer.Launch).To(BeFalse())
+				Expect(filepath.Join(layersDir, "dotnet-core-runtime-downloads", "some-sha")).To(BeARegularFile())
+			})
+		})
+
+		context("when BP_DOTNET_RUNTIME_FORCE_INSTALL is true", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_DOTNET_RUNTIME_FORCE_INSTALL", "true")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_DOTNET_RUNTIME_FORCE_INSTALL")).To(Succeed())
+			})
+
+			it("installs the runtime for launch", func() {
+				result, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(dependencyManager.DeliverCall.CallCount).To(Equal(1))
+				Expect(buffer.String()).NotTo(ContainSubstring("Skipping installation"))
+
+				layer := result.Layers[0]
+				Expect(layer.Name).To(Equal("dotnet-core-runtime"))
+				Expect(layer.Launch).To(BeTrue())
+				Expect(layer.Build).To(BeFalse())
+			})
+		})
+
+		context("when the self-contained application is in the project directory", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(workingDir, "src", "app"), os.ModePerm)).To(Succeed())
+				Expect(os.Rename(filepath.Join(workingDir, "app.runtimeconfig.json"), filepath.Join(workingDir, "src", "app", "app.runtimeconfig.json"))).To(Succeed())
+				Expect(os.Setenv("BP_DOTNET_PROJECT_PATH", "src/app")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_DOTNET_PROJECT_PATH")).To(Succeed())
+			})
+
+			it("skips the installation", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(dependencyManager.DeliverCall.CallCount).To(Equal(0))
+				Expect(buffer.String()).To(ContainSubstring("Skipping installation of .NET Core Runtime: the application is self-contained"))
+			})
+		})
+
+		context("when another buildpack requires the runtime at launch", func() {
+			it.Before(func() {
+				entryResolver.MergeLayerTypesCall.Returns.Launch = true
+			})
+
+			it("installs the runtime", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(dependencyManager.DeliverCall.CallCount).To(Equal(1))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when BP_DOTNET_RUNTIME_FORCE_INSTALL is not a boolean", func() {
+				it.Before(func() {
+					Expect(os.Setenv("BP_DOTNET_RUNTIME_FORCE_INSTALL", "please")).To(Succeed())
+				})
+
+				it.After(func() {
+					Expect(os.Unsetenv("BP_DOTNET_RUNTIME_FORCE_INSTALL")).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := build(packit.BuildContext{
+						WorkingDir: workingDir,
+						CNBPath:    cnbDir,
+						BuildpackInfo: packit.BuildpackInfo{
+							Version: "some-version",
+						},
+						Layers: packit.Layers{Path: layersDir},
+					})
+					Expect(err).To(MatchError(ContainSubstring(`failed to parse BP_DOTNET_RUNTIME_FORCE_INSTALL value "please"`)))
+				})
+			})
+		})
+	})
+
+	context("when BP_DOTNET_RUNTIME_LAUNCH is set", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_DOTNET_RUNTIME_LAUNCH", "true")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_DOTNET_RUNTIME_LAUNCH")).To(Succeed())
+		})
+
+		it("logs the resulting layer types", func() {
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "some-version",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).To(ContainSubstring("Layer types configured by BP_DOTNET_RUNTIME_LAUNCH and BP_DOTNET_RUNTIME_BUILD"))
+			Expect(buffer.String()).To(ContainSubstring("Available at app launch: true"))
+			Expect(buffer.String()).To(ContainSubstring("Available to other buildpacks: false"))
+		})
 
-		Expect(sbomGenerator.GenerateFromDependencyCall.Receives.Dependency).To(Equal(postal.Dependency{
-			ID:      "dotnet-runtime",
-			Version: "2.5.x",
-			Name:    ".NET Core Runtime",
-			SHA256:  "some-sha", //nolint:staticcheck
-		}))
-		Expect(sbomGenerator.GenerateFromDependencyCall.Receives.Dir).To(Equal(filepath.Join(layersDir, "dotnet-core-runtime")))
+		context("when it is not a boolean", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_DOTNET_RUNTIME_LAUNCH", "maybe")).To(Succeed())
+			})
 
-		Expect(buffer.String()).To(ContainSubstring("Some Buildpack some-version"))
-		Expect(buffer.String()).To(ContainSubstring("Resolving .NET Core Runtime version"))
-		Expect(buffer.String()).To(ContainSubstring("Selected .NET Core Runtime version (using BP_DOTNET_FRAMEWORK_VERSION): "))
-		Expect(buffer.String()).To(ContainSubstring("Executing build process"))
-		Expect(buffer.String()).To(ContainSubstring("Configuring build environment"))
-		Expect(buffer.String()).To(ContainSubstring("Configuring launch environment"))
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					BuildpackInfo: packit.BuildpackInfo{
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError(ContainSubstring(`failed to parse BP_DOTNET_RUNTIME_LAUNCH value "maybe"`)))
+			})
+		})
 	})
 
-	context("when there is a dependency cache match", func() {
+	context("when nothing requires the runtime at launch or build time", func() {
 		it.Before(func() {
-			entryResolver.MergeLayerTypesCall.Returns.Build = true
 			entryResolver.MergeLayerTypesCall.Returns.Launch = false
+			entryResolver.MergeLayerTypesCall.Returns.Build = false
+		})
 
-			err := os.WriteFile(filepath.Join(layersDir, "dotnet-core-runtime.toml"), []byte("[metadata]\ndependency-sha = \"some-sha\"\n"), 0600)
+		it("warns that the runtime will not be kept", func() {
+			result, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "some-version",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
 			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[0].Launch).To(BeFalse())
+			Expect(result.Layers[0].Build).To(BeFalse())
+			Expect(buffer.String()).To(ContainSubstring("WARNING: nothing requires .NET Core Runtime at launch or build time"))
+			Expect(buffer.String()).To(ContainSubstring("Set BP_DOTNET_RUNTIME_LAUNCH=true or BP_DOTNET_RUNTIME_BUILD=true"))
+		})
+	})
+
+	context("when the download cache holds more than BP_DOTNET_RUNTIME_DOWNLOAD_CACHE_SIZE", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_DOTNET_RUNTIME_DOWNLOAD_CACHE_SIZE", "1")).To(Succeed())
+
+			versionResolver.ResolveCall.Returns.Dependency.SHA256 = "current-sha" //nolint:staticcheck
+
+			downloadsDir := filepath.Join(layersDir, "dotnet-core-runtime-downloads")
+			Expect(os.MkdirAll(downloadsDir, os.ModePerm)).To(Succeed())
+			for i, name := range []string{"oldest-sha", "older-sha", "current-sha"} {
+				path := filepath.Join(downloadsDir, name)
+				Expect(os.WriteFile(path, bytes.Repeat([]byte("x"), 400*1024), 0600)).To(Succeed())
+
+				modTime := time.Now().Add(time.Duration(i-3) * time.Hour)
+				Expect(os.Chtimes(path, modTime, modTime)).To(Succeed())
+			}
+			Expect(os.WriteFile(filepath.Join(downloadsDir, ".download-123"), nil, 0600)).To(Succeed())
 		})
 
-		it("returns a result that installs the dotnet runtime libraries", func() {
+		it.After(func() {
+			Expect(os.Unsetenv("BP_DOTNET_RUNTIME_DOWNLOAD_CACHE_SIZE")).To(Succeed())
+		})
+
+		it("removes the least recently used tarballs", func() {
 			_, err := build(packit.BuildContext{
 				WorkingDir: workingDir,
 				CNBPath:    cnbDir,
@@ -244,87 +1423,106 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 					Name:    "Some Buildpack",
 					Version: "some-version",
 				},
-				Plan: packit.BuildpackPlan{
-					Entries: []packit.BuildpackPlanEntry{
-						{
-							Name: "dotnet-runtime",
-							Metadata: map[string]interface{}{
-								"version-source": "BP_DOTNET_FRAMEWORK_VERSION",
-								"version":        "2.5.x",
-								"launch":         true,
-							},
-						},
-					},
-				},
 				Layers: packit.Layers{Path: layersDir},
 			})
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(entryResolver.ResolveCall.Receives.BuildpackPlanEntrySlice).To(Equal([]packit.BuildpackPlanEntry{
-				{
-					Name: "dotnet-runtime",
-					Metadata: map[string]interface{}{
-						"version-source": "BP_DOTNET_FRAMEWORK_VERSION",
-						"version":        "2.5.x",
-						"launch":         true,
-					},
-				},
-			}))
+			downloadsDir := filepath.Join(layersDir, "dotnet-core-runtime-downloads")
+			Expect(filepath.Join(downloadsDir, "oldest-sha")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(downloadsDir, "older-sha")).To(BeARegularFile())
+			Expect(filepath.Join(downloadsDir, "current-sha")).To(BeARegularFile())
+			Expect(filepath.Join(downloadsDir, ".download-123")).NotTo(BeAnExistingFile())
 
-			Expect(dependencyManager.GenerateBillOfMaterialsCall.Receives.Dependencies).To(Equal([]postal.Dependency{
-				{
-					ID:      "dotnet-runtime",
-					Version: "2.5.x",
-					Name:    ".NET Core Runtime",
-					SHA256:  "some-sha", //nolint:staticcheck
-				},
-			}))
+			Expect(buffer.String()).To(ContainSubstring("Pruning the download cache to 1 MiB"))
+			Expect(buffer.String()).To(ContainSubstring("Removed sha256:oldest-sha"))
+		})
+	})
 
-			Expect(versionResolver.ResolveCall.Receives.Path).To(Equal(filepath.Join(cnbDir, "buildpack.toml")))
-			Expect(versionResolver.ResolveCall.Receives.Entry).To(Equal(entryResolver.ResolveCall.Returns.BuildpackPlanEntry))
-			Expect(versionResolver.ResolveCall.Receives.Stack).To(Equal("some-stack"))
+	context("when BP_DOTNET_RUNTIME_DOWNLOAD_CACHE_SIZE is 0", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_DOTNET_RUNTIME_DOWNLOAD_CACHE_SIZE", "0")).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(layersDir, "dotnet-core-runtime-downloads"), os.ModePerm)).To(Succeed())
+		})
 
-			Expect(dependencyManager.DeliverCall.CallCount).To(Equal(0))
+		it.After(func() {
+			Expect(os.Unsetenv("BP_DOTNET_RUNTIME_DOWNLOAD_CACHE_SIZE")).To(Succeed())
+		})
 
-			Expect(dotnetSymlinker.LinkCall.CallCount).To(Equal(1))
-			Expect(dotnetSymlinker.LinkCall.Receives.WorkingDir).To(Equal(workingDir))
-			Expect(dotnetSymlinker.LinkCall.Receives.LayerPath).To(Equal(filepath.Join(layersDir, "dotnet-core-runtime")))
+		it("removes the download cache layer", func() {
+			result, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "some-version",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
 
-			Expect(buffer.String()).To(ContainSubstring("Some Buildpack some-version"))
-			Expect(buffer.String()).To(ContainSubstring("Resolving .NET Core Runtime version"))
-			Expect(buffer.String()).To(ContainSubstring("Selected .NET Core Runtime version (using BP_DOTNET_FRAMEWORK_VERSION): "))
-			Expect(buffer.String()).To(ContainSubstring("Reusing cached layer"))
-			Expect(buffer.String()).NotTo(ContainSubstring("Executing build process"))
+			for _, layer := range result.Layers {
+				Expect(layer.Name).NotTo(Equal("dotnet-core-runtime-downloads"))
+			}
+			Expect(filepath.Join(layersDir, "dotnet-core-runtime-downloads")).NotTo(BeAnExistingFile())
+		})
+
+		context("when it is not a number", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_DOTNET_RUNTIME_DOWNLOAD_CACHE_SIZE", "1GB")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					BuildpackInfo: packit.BuildpackInfo{
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError(ContainSubstring(`failed to parse BP_DOTNET_RUNTIME_DOWNLOAD_CACHE_SIZE value "1GB"`)))
+			})
 		})
 	})
 
-	context("when version-source of the selected entry is buildpack.yml", func() {
+	context("when BP_DOTNET_RUNTIME_DRY_RUN is true", func() {
 		it.Before(func() {
-			entryResolver.ResolveCall.Returns.BuildpackPlanEntry = packit.BuildpackPlanEntry{
-				Name: "dotnet-runtime",
-				Metadata: map[string]interface{}{
-					"version-source": "buildpack.yml",
-					"version":        "2.5.x",
-					"launch":         true,
-				},
+			Expect(os.Setenv("BP_DOTNET_RUNTIME_DRY_RUN", "true")).To(Succeed())
+
+			entryResolver.ResolveCall.Returns.BuildpackPlanEntrySlice = []packit.BuildpackPlanEntry{
+				entryResolver.ResolveCall.Returns.BuildpackPlanEntry,
+			}
+
+			versionResolver.ResolveCall.Returns.Dependency = postal.Dependency{
+				ID:              "dotnet-runtime",
+				Version:         "2.5.x",
+				Name:            ".NET Core Runtime",
+				URI:             "some-uri",
+				SHA256:          "some-sha", //nolint:staticcheck
+				DeprecationDate: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
 			}
 		})
 
-		it("prints a deprecation warning", func() {
-			_, err := build(packit.BuildContext{
+		it.After(func() {
+			Expect(os.Unsetenv("BP_DOTNET_RUNTIME_DRY_RUN")).To(Succeed())
+		})
+
+		it("writes the runtime plan to a report layer without installing the runtime", func() {
+			result, err := build(packit.BuildContext{
 				WorkingDir: workingDir,
 				CNBPath:    cnbDir,
 				Stack:      "some-stack",
 				BuildpackInfo: packit.BuildpackInfo{
 					Name:    "Some Buildpack",
-					Version: "0.1.2",
+					Version: "some-version",
 				},
 				Plan: packit.BuildpackPlan{
 					Entries: []packit.BuildpackPlanEntry{
 						{
 							Name: "dotnet-runtime",
 							Metadata: map[string]interface{}{
-								"version-source": "buildpack.yml",
+								"version-source": "BP_DOTNET_FRAMEWORK_VERSION",
 								"version":        "2.5.x",
 								"launch":         true,
 							},
@@ -334,15 +1532,179 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 				Layers: packit.Layers{Path: layersDir},
 			})
 			Expect(err).NotTo(HaveOccurred())
-			Expect(buffer.String()).To(ContainSubstring("Some Buildpack 0.1.2"))
-			Expect(buffer.String()).To(ContainSubstring("Resolving .NET Core Runtime version"))
-			Expect(buffer.String()).To(ContainSubstring("Selected .NET Core Runtime version (using buildpack.yml): "))
-			// v1.0.0 because that's the next major after input version v0.1.2
-			Expect(buffer.String()).To(ContainSubstring("WARNING: Setting the .NET Framework version through buildpack.yml will be deprecated soon in .NET Core Runtime Buildpack v1.0.0."))
-			Expect(buffer.String()).To(ContainSubstring("Please specify the version through the $BP_DOTNET_FRAMEWORK_VERSION environment variable instead. See docs for more information."))
-			Expect(buffer.String()).To(ContainSubstring("Executing build process"))
-			Expect(buffer.String()).To(ContainSubstring("Configuring build environment"))
+
+			Expect(result.Layers).To(HaveLen(1))
+			layer := result.Layers[0]
+			Expect(layer.Name).To(Equal("dotnet-core-runtime-report"))
+			Expect(layer.Build).To(BeTrue())
+
+			Expect(dependencyManager.DeliverCall.CallCount).To(Equal(0))
+			Expect(dotnetSymlinker.LinkCall.CallCount).To(Equal(0))
+			Expect(sbomGenerator.GenerateFromDependencyCall.CallCount).To(Equal(0))
+
+			content, err := os.ReadFile(filepath.Join(layersDir, "dotnet-core-runtime-report", "report.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(MatchJSON(fmt.Sprintf(`{
+				"dry-run": true,
+				"version-source": "BP_DOTNET_FRAMEWORK_VERSION",
+				"requested-version": "2.5.x",
+				"candidates": [
+					{
+						"version-source": "BP_DOTNET_FRAMEWORK_VERSION",
+						"version": "2.5.x",
+						"launch": true,
+						"build": false
+					}
+				],
+				"dependency": {
+					"id": "dotnet-runtime",
+					"name": ".NET Core Runtime",
+					"version": "2.5.x",
+					"uri": "some-uri",
+					"sha256": "some-sha",
+					"deprecation-date": "2000-01-01T00:00:00Z",
+					"deprecated": true
+				},
+				"cache-reused": false,
+				"install-duration-ms": 0,
+				"sbom-duration-ms": 0,
+				"launch": true,
+				"build": false,
+				"launch-env": {
+					"DOTNET_ROOT.override": %q,
+					"BPI_DOTNET_RUNTIME_VERSION.override": "2.5.x",
+					"BPI_DOTNET_RUNTIME_MANIFEST.override": %q
+				},
+				"build-env": {
+					"RUNTIME_VERSION.override": "2.5.x"
+				}
+			}`, filepath.Join(workingDir, ".dotnet_root"), filepath.Join(layersDir, "dotnet-core-runtime", "runtime-manifest.json"))))
+
+			Expect(buffer.String()).To(ContainSubstring("Dry run: skipping installation of .NET Core Runtime 2.5.x"))
+			Expect(buffer.String()).To(ContainSubstring("Version 2.5.x of .NET Core Runtime is deprecated."))
 			Expect(buffer.String()).To(ContainSubstring("Configuring launch environment"))
+			Expect(buffer.String()).To(ContainSubstring(fmt.Sprintf("Wrote runtime report to %s", filepath.Join(layersDir, "dotnet-core-runtime-report", "report.json"))))
+			Expect(buffer.String()).NotTo(ContainSubstring("Executing build process"))
+		})
+
+		context("when a runtime layer from an earlier build is cached", func() {
+			var buildContext packit.BuildContext
+
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(layersDir, "dotnet-core-runtime.toml"), []byte("[metadata]\ndependency-checksum = \"some-sha\"\n"), 0600)).To(Succeed())
+				Expect(os.MkdirAll(filepath.Join(layersDir, "dotnet-core-runtime"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(layersDir, "dotnet-core-runtime", "dotnet"), nil, 0700)).To(Succeed())
+
+				buildContext = packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Plan: packit.BuildpackPlan{
+						Entries: []packit.BuildpackPlanEntry{
+							{
+								Name: "dotnet-runtime",
+								Metadata: map[string]interface{}{
+									"version-source": "BP_DOTNET_FRAMEWORK_VERSION",
+									"version":        "2.5.x",
+									"launch":         true,
+								},
+							},
+						},
+					},
+					Layers: packit.Layers{Path: layersDir},
+				}
+			})
+
+			it("returns the layer unchanged so that the next build reuses it", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Layers).To(HaveLen(2))
+				layer := result.Layers[0]
+				Expect(layer.Name).To(Equal("dotnet-core-runtime"))
+				Expect(layer.Launch).To(BeTrue())
+				Expect(layer.Metadata).To(Equal(map[string]interface{}{
+					"dependency-checksum": "some-sha",
+				}))
+				Expect(filepath.Join(layersDir, "dotnet-core-runtime", "dotnet")).To(BeARegularFile())
+
+				Expect(os.Unsetenv("BP_DOTNET_RUNTIME_DRY_RUN")).To(Succeed())
+
+				_, err = build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(dependencyManager.DeliverCall.CallCount).To(Equal(0))
+				Expect(buffer.String()).To(ContainSubstring("Reusing cached layer"))
+			})
+		})
+
+		context("when BP_DOTNET_RUNTIME_REPORT_PATH is set", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_DOTNET_RUNTIME_REPORT_PATH", "reports/runtime.json")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_DOTNET_RUNTIME_REPORT_PATH")).To(Succeed())
+			})
+
+			it("writes the runtime plan to the given path relative to the working directory", func() {
+				result, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Layers).To(BeEmpty())
+
+				content, err := os.ReadFile(filepath.Join(workingDir, "reports", "runtime.json"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(content)).To(ContainSubstring(`"dry-run": true`))
+
+				Expect(dependencyManager.DeliverCall.CallCount).To(Equal(0))
+				Expect(buffer.String()).To(ContainSubstring("WARNING: the runtime report is written inside the application directory, so the image changes on every rebuild"))
+			})
+
+			context("when the path is outside the working directory", func() {
+				var reportDir string
+
+				it.Before(func() {
+					var err error
+					reportDir, err = os.MkdirTemp("", "report")
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(os.Setenv("BP_DOTNET_RUNTIME_REPORT_PATH", filepath.Join(reportDir, "runtime.json"))).To(Succeed())
+				})
+
+				it.After(func() {
+					Expect(os.RemoveAll(reportDir)).To(Succeed())
+				})
+
+				it("writes the runtime plan to the given path without a warning", func() {
+					_, err := build(packit.BuildContext{
+						WorkingDir: workingDir,
+						CNBPath:    cnbDir,
+						Stack:      "some-stack",
+						BuildpackInfo: packit.BuildpackInfo{
+							Name:    "Some Buildpack",
+							Version: "some-version",
+						},
+						Layers: packit.Layers{Path: layersDir},
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(filepath.Join(reportDir, "runtime.json")).To(BeARegularFile())
+					Expect(buffer.String()).NotTo(ContainSubstring("inside the application directory"))
+				})
+			})
 		})
 	})
 
@@ -459,6 +1821,38 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("when the runtime manifest cannot be written", func() {
+			it.Before(func() {
+				dependencyManager.DeliverCall.Stub = func(_ postal.Dependency, _, layerPath, _ string) error {
+					return os.Chmod(layerPath, 0500)
+				}
+			})
+
+			it.After(func() {
+				Expect(os.Chmod(filepath.Join(layersDir, "dotnet-core-runtime"), os.ModePerm)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					CNBPath: cnbDir,
+					Plan: packit.BuildpackPlan{
+						Entries: []packit.BuildpackPlanEntry{
+							{
+								Name: "dotnet-core-runtime",
+								Metadata: map[string]interface{}{
+									"version-source": "BP_DOTNET_FRAMEWORK_VERSION",
+									"version":        "2.5.x",
+								},
+							},
+						},
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError(ContainSubstring("failed to write runtime manifest")))
+				Expect(err).To(MatchError(ContainSubstring("permission denied")))
+			})
+		})
+
 		context("when generating the SBOM returns an error", func() {
 			it.Before(func() {
 				sbomGenerator.GenerateFromDependencyCall.Returns.Error = errors.New("failed to generate SBOM")
@@ -505,5 +1899,26 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 				Expect(err).To(MatchError("unsupported SBOM format: 'random-format'"))
 			})
 		})
+
+		context("when BP_DOTNET_RUNTIME_DRY_RUN is not a boolean", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_DOTNET_RUNTIME_DRY_RUN", "sometimes")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_DOTNET_RUNTIME_DRY_RUN")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					CNBPath: cnbDir,
+					BuildpackInfo: packit.BuildpackInfo{
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError(ContainSubstring(`failed to parse BP_DOTNET_RUNTIME_DRY_RUN value "sometimes"`)))
+			})
+		})
 	})
 }
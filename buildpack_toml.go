@@ -0,0 +1,47 @@
+package dotnetcoreruntime
+
+import (
+	"github.com/BurntSushi/toml"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+)
+
+// BuildpackTOML is the part of buildpack.toml that describes the dependencies
+// the buildpack can install.
+type BuildpackTOML struct {
+	Metadata BuildpackTOMLMetadata `toml:"metadata"`
+	Stacks   []BuildpackTOMLStack  `toml:"stacks"`
+}
+
+type BuildpackTOMLMetadata struct {
+	DefaultVersions       map[string]string      `toml:"default-versions"`
+	Dependencies          []postal.Dependency    `toml:"dependencies"`
+	DependencyConstraints []DependencyConstraint `toml:"dependency-constraints"`
+	SDKRuntimeMappings    []SDKRuntimeMapping    `toml:"sdk-runtime-mappings"`
+}
+
+type DependencyConstraint struct {
+	Constraint string `toml:"constraint"`
+	ID         string `toml:"id"`
+	Patches    int    `toml:"patches"`
+}
+
+// SDKRuntimeMapping maps an SDK feature band (6.0.4xx) or version constraint
+// (6.0.*) to the runtime version line that the SDK ships with.
+type SDKRuntimeMapping struct {
+	SDK     string `toml:"sdk"`
+	Runtime string `toml:"runtime"`
+}
+
+type BuildpackTOMLStack struct {
+	ID string `toml:"id"`
+}
+
+func ParseBuildpackTOML(path string) (BuildpackTOML, error) {
+	var buildpackTOML BuildpackTOML
+	_, err := toml.DecodeFile(path, &buildpackTOML)
+	if err != nil {
+		return BuildpackTOML{}, err
+	}
+
+	return buildpackTOML, nil
+}
@@ -0,0 +1,105 @@
+package dotnetcoreruntime_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	dotnetcoreruntime "github.com/paketo-buildpacks/dotnet-core-runtime"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testBuildpackTOML(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		path string
+	)
+
+	it.Before(func() {
+		file, err := os.CreateTemp("", "buildpack.toml")
+		Expect(err).NotTo(HaveOccurred())
+		defer file.Close()
+
+		_, err = file.WriteString(`api = "0.8"
+[buildpack]
+  id = "org.some-org.some-buildpack"
+
+[metadata]
+  [metadata.default-versions]
+    dotnet-runtime = "1.2.*"
+
+  [[metadata.dependencies]]
+    id = "dotnet-runtime"
+    sha256 = "some-sha"
+    stacks = ["some-stack"]
+    uri = "some-uri"
+    version = "1.2.3"
+
+  [[metadata.dependency-constraints]]
+    constraint = "1.2.*"
+    id = "dotnet-runtime"
+    patches = 2
+
+[[stacks]]
+  id = "some-stack"
+`)
+		Expect(err).NotTo(HaveOccurred())
+
+		path = file.Name()
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(path)).To(Succeed())
+	})
+
+	context("ParseBuildpackTOML", func() {
+		it("parses the dependency metadata and stacks", func() {
+			buildpackTOML, err := dotnetcoreruntime.ParseBuildpackTOML(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buildpackTOML).To(Equal(dotnetcoreruntime.BuildpackTOML{
+				Metadata: dotnetcoreruntime.BuildpackTOMLMetadata{
+					DefaultVersions: map[string]string{"dotnet-runtime": "1.2.*"},
+					Dependencies: []postal.Dependency{
+						{
+							ID:      "dotnet-runtime",
+							SHA256:  "some-sha", //nolint:staticcheck
+							Stacks:  []string{"some-stack"},
+							URI:     "some-uri",
+							Version: "1.2.3",
+						},
+					},
+					DependencyConstraints: []dotnetcoreruntime.DependencyConstraint{
+						{Constraint: "1.2.*", ID: "dotnet-runtime", Patches: 2},
+					},
+				},
+				Stacks: []dotnetcoreruntime.BuildpackTOMLStack{
+					{ID: "some-stack"},
+				},
+			}))
+		})
+
+		context("failure cases", func() {
+			context("when the file does not exist", func() {
+				it("returns an error", func() {
+					_, err := dotnetcoreruntime.ParseBuildpackTOML(filepath.Join(path, "missing"))
+					Expect(err).To(MatchError(ContainSubstring("not a directory")))
+				})
+			})
+
+			context("when the file is malformed", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(path, []byte("%%%"), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := dotnetcoreruntime.ParseBuildpackTOML(path)
+					Expect(err).To(MatchError(ContainSubstring("expected '.' or '=', but got '%' instead")))
+				})
+			})
+		})
+	})
+}
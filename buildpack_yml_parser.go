@@ -1,18 +1,49 @@
 package dotnetcoreruntime
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 
+	"github.com/Masterminds/semver"
 	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
 )
 
-type BuildpackYMLParser struct{}
+type BuildpackYMLParser struct {
+	strict bool
+}
 
 func NewBuildpackYMLParser() BuildpackYMLParser {
 	return BuildpackYMLParser{}
 }
 
+// WithStrict returns a parser that, when strict is true, validates the
+// dotnet-framework section of buildpack.yml and reports every problem with
+// its line and column instead of ignoring what it does not understand. The
+// BP_DOTNET_BUILDPACK_YML_STRICT environment variable takes precedence.
+func (p BuildpackYMLParser) WithStrict(strict bool) BuildpackYMLParser {
+	p.strict = strict
+	return p
+}
+
 func (p BuildpackYMLParser) ParseVersion(path string) (string, error) {
+	strict := p.strict
+	if value, ok := os.LookupEnv("BP_DOTNET_BUILDPACK_YML_STRICT"); ok && value != "" {
+		var err error
+		strict, err = strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse BP_DOTNET_BUILDPACK_YML_STRICT value %q: %w", value, err)
+		}
+	}
+
+	if strict {
+		return parseBuildpackYMLStrict(path)
+	}
+
 	var buildpack struct {
 		DotnetFramework struct {
 			Version string `yaml:"version"`
@@ -34,3 +65,86 @@ func (p BuildpackYMLParser) ParseVersion(path string) (string, error) {
 
 	return buildpack.DotnetFramework.Version, nil
 }
+
+// misspelledFrameworkKey matches top-level keys that are most likely meant to
+// be dotnet-framework, such as dotnet_framework or dotnetFramework.
+var misspelledFrameworkKey = regexp.MustCompile(`(?i)^dot-?net[-_ ]?framework$`)
+
+func parseBuildpackYMLStrict(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	var document yamlv3.Node
+	err = yamlv3.Unmarshal(content, &document)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if len(document.Content) == 0 {
+		return "", nil
+	}
+
+	var problems []string
+	problem := func(node *yamlv3.Node, format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf("%s:%d:%d: %s", path, node.Line, node.Column, fmt.Sprintf(format, args...)))
+	}
+
+	root := document.Content[0]
+	if root.Kind != yamlv3.MappingNode {
+		problem(root, "expected a mapping at the top level")
+		return "", strictParseError(problems)
+	}
+
+	var version string
+	for i := 0; i < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+
+		if key.Value != "dotnet-framework" {
+			if misspelledFrameworkKey.MatchString(key.Value) {
+				problem(key, "unknown key %q, did you mean \"dotnet-framework\"?", key.Value)
+			}
+			continue
+		}
+
+		if value.Kind != yamlv3.MappingNode {
+			problem(value, "dotnet-framework must be a mapping")
+			continue
+		}
+
+		for j := 0; j < len(value.Content); j += 2 {
+			frameworkKey, frameworkValue := value.Content[j], value.Content[j+1]
+
+			if frameworkKey.Value != "version" {
+				problem(frameworkKey, "unknown key %q in dotnet-framework (supported keys: version)", frameworkKey.Value)
+				continue
+			}
+
+			if frameworkValue.Kind != yamlv3.ScalarNode {
+				problem(frameworkValue, "dotnet-framework.version must be a string")
+				continue
+			}
+
+			if _, err := semver.NewConstraint(frameworkValue.Value); err != nil {
+				problem(frameworkValue, "dotnet-framework.version %q is not a valid version constraint: %s", frameworkValue.Value, err)
+				continue
+			}
+
+			version = frameworkValue.Value
+		}
+	}
+
+	if len(problems) > 0 {
+		return "", strictParseError(problems)
+	}
+
+	return version, nil
+}
+
+func strictParseError(problems []string) error {
+	return fmt.Errorf("invalid buildpack.yml:\n  %s", strings.Join(problems, "\n  "))
+}
@@ -85,5 +85,171 @@ dotnet-framework:
 				})
 			})
 		})
+
+		context("when strict parsing is enabled", func() {
+			it.Before(func() {
+				parser = parser.WithStrict(true)
+			})
+
+			it("parses the dotnet framework version from a buildpack.yml file", func() {
+				version, err := parser.ParseVersion(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(Equal("1.2.3"))
+			})
+
+			it("ignores unrelated top-level keys", func() {
+				Expect(os.WriteFile(path, []byte(`---
+dotnet-build:
+  project-path: src/app
+dotnet-framework:
+  version: 6.0.*
+`), 0644)).To(Succeed())
+
+				version, err := parser.ParseVersion(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(Equal("6.0.*"))
+			})
+
+			context("when the buildpack.yml file does not exist", func() {
+				it.Before(func() {
+					Expect(os.Remove(path)).To(Succeed())
+				})
+
+				it("returns an empty version", func() {
+					version, err := parser.ParseVersion(path)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(version).To(BeEmpty())
+				})
+			})
+
+			context("when the buildpack.yml file is empty", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(path, nil, 0644)).To(Succeed())
+				})
+
+				it("returns an empty version", func() {
+					version, err := parser.ParseVersion(path)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(version).To(BeEmpty())
+				})
+			})
+
+			context("when BP_DOTNET_BUILDPACK_YML_STRICT is set", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(path, []byte(`---
+dotnet-framework:
+  verison: 1.2.3
+`), 0644)).To(Succeed())
+
+					parser = parser.WithStrict(false)
+					os.Setenv("BP_DOTNET_BUILDPACK_YML_STRICT", "true")
+				})
+
+				it.After(func() {
+					os.Unsetenv("BP_DOTNET_BUILDPACK_YML_STRICT")
+				})
+
+				it("overrides the parser setting", func() {
+					_, err := parser.ParseVersion(path)
+					Expect(err).To(MatchError(ContainSubstring(`unknown key "verison" in dotnet-framework`)))
+				})
+			})
+
+			context("failure cases", func() {
+				context("when the dotnet-framework key is misspelled", func() {
+					it.Before(func() {
+						Expect(os.WriteFile(path, []byte(`---
+dotnet_framework:
+  version: 1.2.3
+`), 0644)).To(Succeed())
+					})
+
+					it("returns an error with the location of the key", func() {
+						_, err := parser.ParseVersion(path)
+						Expect(err).To(MatchError(ContainSubstring(path + `:2:1: unknown key "dotnet_framework", did you mean "dotnet-framework"?`)))
+					})
+				})
+
+				context("when dotnet-framework is not a mapping", func() {
+					it.Before(func() {
+						Expect(os.WriteFile(path, []byte(`---
+dotnet-framework: 1.2.3
+`), 0644)).To(Succeed())
+					})
+
+					it("returns an error", func() {
+						_, err := parser.ParseVersion(path)
+						Expect(err).To(MatchError(ContainSubstring(path + ":2:19: dotnet-framework must be a mapping")))
+					})
+				})
+
+				context("when the top level is not a mapping", func() {
+					it.Before(func() {
+						Expect(os.WriteFile(path, []byte("- dotnet-framework\n"), 0644)).To(Succeed())
+					})
+
+					it("returns an error", func() {
+						_, err := parser.ParseVersion(path)
+						Expect(err).To(MatchError(ContainSubstring(path + ":1:1: expected a mapping at the top level")))
+					})
+				})
+
+				context("when the version is not a string", func() {
+					it.Before(func() {
+						Expect(os.WriteFile(path, []byte(`---
+dotnet-framework:
+  version: [1.2.3]
+`), 0644)).To(Succeed())
+					})
+
+					it("returns an error", func() {
+						_, err := parser.ParseVersion(path)
+						Expect(err).To(MatchError(ContainSubstring(path + ":3:12: dotnet-framework.version must be a string")))
+					})
+				})
+
+				context("when there are several problems", func() {
+					it.Before(func() {
+						Expect(os.WriteFile(path, []byte(`---
+dotnet-framework:
+  verison: 1.2.3
+  version: not-a-version
+`), 0644)).To(Succeed())
+					})
+
+					it("reports all of them", func() {
+						_, err := parser.ParseVersion(path)
+						Expect(err).To(MatchError(ContainSubstring(path + `:3:3: unknown key "verison" in dotnet-framework (supported keys: version)`)))
+						Expect(err).To(MatchError(ContainSubstring(path + `:4:12: dotnet-framework.version "not-a-version" is not a valid version constraint`)))
+					})
+				})
+
+				context("when the contents of the buildpack.yml file are malformed", func() {
+					it.Before(func() {
+						Expect(os.WriteFile(path, []byte("%%%"), 0644)).To(Succeed())
+					})
+
+					it("returns an error", func() {
+						_, err := parser.ParseVersion(path)
+						Expect(err).To(MatchError(ContainSubstring("failed to parse " + path)))
+					})
+				})
+
+				context("when BP_DOTNET_BUILDPACK_YML_STRICT is invalid", func() {
+					it.Before(func() {
+						os.Setenv("BP_DOTNET_BUILDPACK_YML_STRICT", "maybe")
+					})
+
+					it.After(func() {
+						os.Unsetenv("BP_DOTNET_BUILDPACK_YML_STRICT")
+					})
+
+					it("returns an error", func() {
+						_, err := parser.ParseVersion(path)
+						Expect(err).To(MatchError(ContainSubstring(`failed to parse BP_DOTNET_BUILDPACK_YML_STRICT value "maybe"`)))
+					})
+				})
+			})
+		})
 	})
 }
@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LookupEnv has the signature of os.LookupEnv.
+type LookupEnv func(key string) (string, bool)
+
+var miniDumpTypes = map[string]string{
+	"mini":   "1",
+	"heap":   "2",
+	"triage": "3",
+	"full":   "4",
+}
+
+// Environment translates the BPL_DOTNET_* diagnostics settings into the
+// DOTNET_* variables that configure the .NET runtime. Settings that are not
+// set produce no variables, so that the build-time default set by
+// BP_DOTNET_RUNTIME_DISABLE_DIAGNOSTICS and any DOTNET_* variables set
+// directly are left alone.
+func Environment(lookupEnv LookupEnv) (map[string]string, error) {
+	env := map[string]string{}
+
+	for _, toggle := range []struct {
+		setting  string
+		variable string
+	}{
+		{"BPL_DOTNET_DIAGNOSTICS", "DOTNET_EnableDiagnostics"},
+		{"BPL_DOTNET_MINIDUMP", "DOTNET_DbgEnableMiniDump"},
+		{"BPL_DOTNET_EVENTPIPE", "DOTNET_EnableEventPipe"},
+	} {
+		value, ok := lookupEnv(toggle.setting)
+		if !ok || value == "" {
+			continue
+		}
+
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s value %q: %w", toggle.setting, value, err)
+		}
+
+		env[toggle.variable] = "0"
+		if enabled {
+			env[toggle.variable] = "1"
+		}
+	}
+
+	if value, ok := lookupEnv("BPL_DOTNET_MINIDUMP_TYPE"); ok && value != "" {
+		dumpType, ok := miniDumpTypes[strings.ToLower(value)]
+		if !ok {
+			return nil, fmt.Errorf("invalid BPL_DOTNET_MINIDUMP_TYPE value %q: must be one of mini, heap, triage or full", value)
+		}
+		env["DOTNET_DbgMiniDumpType"] = dumpType
+	}
+
+	for _, setting := range []struct {
+		setting  string
+		variable string
+	}{
+		{"BPL_DOTNET_MINIDUMP_PATH", "DOTNET_DbgMiniDumpName"},
+		{"BPL_DOTNET_EVENTPIPE_CONFIG", "DOTNET_EventPipeConfig"},
+		{"BPL_DOTNET_EVENTPIPE_OUTPUT_PATH", "DOTNET_EventPipeOutputPath"},
+	} {
+		if value, ok := lookupEnv(setting.setting); ok && value != "" {
+			env[setting.variable] = value
+		}
+	}
+
+	return env, nil
+}
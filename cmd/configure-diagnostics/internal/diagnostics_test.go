@@ -0,0 +1,89 @@
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/paketo-buildpacks/dotnet-core-runtime/cmd/configure-diagnostics/internal"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testDiagnostics(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		env       map[string]string
+		lookupEnv internal.LookupEnv
+	)
+
+	it.Before(func() {
+		env = map[string]string{}
+		lookupEnv = func(key string) (string, bool) {
+			value, ok := env[key]
+			return value, ok
+		}
+	})
+
+	context("Environment", func() {
+		it("sets nothing when no settings are given", func() {
+			result, err := internal.Environment(lookupEnv)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(BeEmpty())
+		})
+
+		it("translates every setting into the runtime variables", func() {
+			env["BPL_DOTNET_DIAGNOSTICS"] = "false"
+			env["BPL_DOTNET_MINIDUMP"] = "true"
+			env["BPL_DOTNET_MINIDUMP_TYPE"] = "Heap"
+			env["BPL_DOTNET_MINIDUMP_PATH"] = "/tmp/dumps/core.%p"
+			env["BPL_DOTNET_EVENTPIPE"] = "1"
+			env["BPL_DOTNET_EVENTPIPE_CONFIG"] = "Microsoft-Windows-DotNETRuntime:4c14fccbd:5"
+			env["BPL_DOTNET_EVENTPIPE_OUTPUT_PATH"] = "/tmp/trace.nettrace"
+
+			result, err := internal.Environment(lookupEnv)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(map[string]string{
+				"DOTNET_EnableDiagnostics":   "0",
+				"DOTNET_DbgEnableMiniDump":   "1",
+				"DOTNET_DbgMiniDumpType":     "2",
+				"DOTNET_DbgMiniDumpName":     "/tmp/dumps/core.%p",
+				"DOTNET_EnableEventPipe":     "1",
+				"DOTNET_EventPipeConfig":     "Microsoft-Windows-DotNETRuntime:4c14fccbd:5",
+				"DOTNET_EventPipeOutputPath": "/tmp/trace.nettrace",
+			}))
+		})
+
+		it("re-enables diagnostics that were disabled at build time", func() {
+			env["BPL_DOTNET_DIAGNOSTICS"] = "true"
+
+			result, err := internal.Environment(lookupEnv)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(map[string]string{"DOTNET_EnableDiagnostics": "1"}))
+		})
+
+		context("failure cases", func() {
+			context("when a toggle is not a boolean", func() {
+				it.Before(func() {
+					env["BPL_DOTNET_MINIDUMP"] = "sometimes"
+				})
+
+				it("returns an error", func() {
+					_, err := internal.Environment(lookupEnv)
+					Expect(err).To(MatchError(ContainSubstring(`failed to parse BPL_DOTNET_MINIDUMP value "sometimes"`)))
+				})
+			})
+
+			context("when the minidump type is unknown", func() {
+				it.Before(func() {
+					env["BPL_DOTNET_MINIDUMP_TYPE"] = "tiny"
+				})
+
+				it("returns an error", func() {
+					_, err := internal.Environment(lookupEnv)
+					Expect(err).To(MatchError(`invalid BPL_DOTNET_MINIDUMP_TYPE value "tiny": must be one of mini, heap, triage or full`))
+				})
+			})
+		})
+	})
+}
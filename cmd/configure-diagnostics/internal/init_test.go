@@ -0,0 +1,14 @@
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitConfigureDiagnostics(t *testing.T) {
+	suite := spec.New("configure-diagnostics", spec.Report(report.Terminal{}))
+	suite("Diagnostics", testDiagnostics)
+	suite.Run(t)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+	"github.com/paketo-buildpacks/dotnet-core-runtime/cmd/configure-diagnostics/internal"
+)
+
+// configure-diagnostics is an exec.d binary that translates the
+// BPL_DOTNET_DIAGNOSTICS, BPL_DOTNET_MINIDUMP* and BPL_DOTNET_EVENTPIPE*
+// settings into the DOTNET_* variables read by the .NET runtime when the
+// container starts, so that diagnostics can be enabled or hardened without
+// rebuilding the image.
+func main() {
+	err := run()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	env, err := internal.Environment(os.LookupEnv)
+	if err != nil {
+		return err
+	}
+
+	if env["DOTNET_DbgEnableMiniDump"] == "1" {
+		createdump := filepath.Join(os.Getenv("DOTNET_ROOT"), "shared", "Microsoft.NETCore.App", os.Getenv("BPI_DOTNET_RUNTIME_VERSION"), "createdump")
+		if _, err := os.Stat(createdump); err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: BPL_DOTNET_MINIDUMP is enabled but %s is not available; the runtime may have been trimmed with BP_DOTNET_RUNTIME_TRIM\n", createdump)
+		}
+	}
+
+	// exec.d binaries report the environment to set on file descriptor 3.
+	output := os.NewFile(3, "/dev/fd/3")
+	defer output.Close()
+
+	return toml.NewEncoder(output).Encode(env)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/paketo-buildpacks/dotnet-core-runtime/validation"
+)
+
+// lint-buildpack-toml reports inconsistencies in the dependency metadata of a
+// buildpack.toml and exits non-zero when any are found.
+//
+// Usage:
+//
+//	lint-buildpack-toml [--buildpack-toml <path>]
+func main() {
+	var buildpackTOML string
+	flag.StringVar(&buildpackTOML, "buildpack-toml", "buildpack.toml", "path to the buildpack.toml to validate")
+	flag.Parse()
+
+	violations, err := validation.ValidateFile(buildpackTOML)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load %s: %s\n", buildpackTOML, err)
+		os.Exit(1)
+	}
+
+	for _, violation := range violations {
+		fmt.Println(violation)
+	}
+
+	if len(violations) > 0 {
+		fmt.Fprintf(os.Stderr, "found %d violation(s) in %s\n", len(violations), buildpackTOML)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s is valid\n", buildpackTOML)
+}
@@ -0,0 +1,14 @@
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitMigrateBuildpackYML(t *testing.T) {
+	suite := spec.New("migrate-buildpack-yml", spec.Report(report.Terminal{}))
+	suite("Migrator", testMigrator)
+	suite.Run(t)
+}
@@ -0,0 +1,256 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	dotnetcoreruntime "github.com/paketo-buildpacks/dotnet-core-runtime"
+	"gopkg.in/yaml.v3"
+)
+
+const EnvironmentVariable = "BP_DOTNET_FRAMEWORK_VERSION"
+
+// Changes describes what a migration did, or would do, to an application.
+type Changes struct {
+	Version             string
+	ProjectTOMLUpdated  bool
+	BuildpackYMLUpdated bool
+	BuildpackYMLRemoved bool
+}
+
+type Migrator struct {
+	parser dotnetcoreruntime.VersionParser
+}
+
+func NewMigrator(parser dotnetcoreruntime.VersionParser) Migrator {
+	return Migrator{parser: parser}
+}
+
+// Migrate moves the dotnet-framework.version setting of the application's
+// buildpack.yml into a BP_DOTNET_FRAMEWORK_VERSION build environment variable
+// in its project.toml. Other keys in buildpack.yml are kept; the file is
+// removed once nothing is left in it. When dryRun is true, nothing is
+// written.
+func (m Migrator) Migrate(appDir string, dryRun bool) (Changes, error) {
+	buildpackYMLPath := filepath.Join(appDir, "buildpack.yml")
+	projectTOMLPath := filepath.Join(appDir, "project.toml")
+
+	version, err := m.parser.ParseVersion(buildpackYMLPath)
+	if err != nil {
+		return Changes{}, fmt.Errorf("failed to parse %s: %w", buildpackYMLPath, err)
+	}
+
+	if version == "" {
+		return Changes{}, nil
+	}
+
+	changes := Changes{Version: version}
+
+	projectTOML, err := os.ReadFile(projectTOMLPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Changes{}, err
+	}
+
+	updatedProjectTOML, err := addBuildEnv(projectTOML, version)
+	if err != nil {
+		return Changes{}, fmt.Errorf("failed to update %s: %w", projectTOMLPath, err)
+	}
+	changes.ProjectTOMLUpdated = !bytes.Equal(projectTOML, updatedProjectTOML)
+
+	buildpackYML, err := os.ReadFile(buildpackYMLPath)
+	if err != nil {
+		return Changes{}, err
+	}
+
+	updatedBuildpackYML, empty, err := removeFrameworkVersion(buildpackYML)
+	if err != nil {
+		return Changes{}, fmt.Errorf("failed to update %s: %w", buildpackYMLPath, err)
+	}
+	changes.BuildpackYMLRemoved = empty
+	changes.BuildpackYMLUpdated = !empty
+
+	if dryRun {
+		return changes, nil
+	}
+
+	if changes.ProjectTOMLUpdated {
+		err = os.WriteFile(projectTOMLPath, updatedProjectTOML, 0644)
+		if err != nil {
+			return Changes{}, err
+		}
+	}
+
+	if empty {
+		err = os.Remove(buildpackYMLPath)
+	} else {
+		err = os.WriteFile(buildpackYMLPath, updatedBuildpackYML, 0644)
+	}
+	if err != nil {
+		return Changes{}, err
+	}
+
+	return changes, nil
+}
+
+// addBuildEnv appends a build environment entry for the version to the
+// project.toml content, using the table that matches its schema version. The
+// existing content is kept as is so that comments and formatting survive.
+// Environments written as an inline array cannot be extended by appending a
+// table, so they are refused and must be edited by hand.
+func addBuildEnv(content []byte, version string) ([]byte, error) {
+	var project struct {
+		Schema struct {
+			Version string `toml:"schema-version"`
+		} `toml:"_"`
+		Build struct {
+			Env []projectEnv `toml:"env"`
+		} `toml:"build"`
+		IO struct {
+			Buildpacks struct {
+				Build struct {
+					Env []projectEnv `toml:"env"`
+				} `toml:"build"`
+			} `toml:"buildpacks"`
+		} `toml:"io"`
+	}
+
+	metadata, err := toml.Decode(string(content), &project)
+	if err != nil {
+		return nil, err
+	}
+
+	key := []string{"build", "env"}
+	env := project.Build.Env
+	if project.Schema.Version == "0.2" {
+		key = []string{"io", "buildpacks", "build", "env"}
+		env = project.IO.Buildpacks.Build.Env
+	}
+	table := strings.Join(key, ".")
+
+	for _, variable := range env {
+		if variable.Name != EnvironmentVariable {
+			continue
+		}
+
+		if variable.Value != version {
+			return nil, fmt.Errorf("%s is already set to %q, which differs from %q in buildpack.yml", EnvironmentVariable, variable.Value, version)
+		}
+
+		return content, nil
+	}
+
+	if metadata.IsDefined(key...) && metadata.Type(key...) != "ArrayHash" {
+		return nil, fmt.Errorf("%s is defined inline, add %s = %q to it by hand", table, EnvironmentVariable, version)
+	}
+
+	var buffer bytes.Buffer
+	buffer.Write(content)
+	if len(content) > 0 {
+		if !bytes.HasSuffix(content, []byte("\n")) {
+			buffer.WriteString("\n")
+		}
+		buffer.WriteString("\n")
+	}
+	fmt.Fprintf(&buffer, "[[%s]]\n  name = %q\n  value = %q\n", table, EnvironmentVariable, version)
+
+	return buffer.Bytes(), nil
+}
+
+type projectEnv struct {
+	Name  string `toml:"name"`
+	Value string `toml:"value"`
+}
+
+// removeFrameworkVersion removes dotnet-framework.version from the
+// buildpack.yml content and reports whether anything is left in it. In
+// block-style documents only the lines holding the setting are removed, so
+// that comments and formatting survive; other documents are re-encoded.
+func removeFrameworkVersion(content []byte) ([]byte, bool, error) {
+	var document yaml.Node
+	err := yaml.Unmarshal(content, &document)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return nil, true, nil
+	}
+
+	root := document.Content[0]
+	textual := root.Style&yaml.FlowStyle == 0
+
+	var lines []int
+	for i := 0; i < len(root.Content); i += 2 {
+		if root.Content[i].Value != "dotnet-framework" || root.Content[i+1].Kind != yaml.MappingNode {
+			continue
+		}
+
+		framework := root.Content[i+1]
+		if framework.Style&yaml.FlowStyle != 0 {
+			textual = false
+		}
+
+		for j := 0; j < len(framework.Content); j += 2 {
+			if framework.Content[j].Value == "version" {
+				key, value := framework.Content[j], framework.Content[j+1]
+				if value.Kind != yaml.ScalarNode || value.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 || value.Line != key.Line || key.Line == root.Content[i].Line {
+					textual = false
+				}
+
+				lines = append(lines, key.Line)
+				framework.Content = append(framework.Content[:j], framework.Content[j+2:]...)
+				break
+			}
+		}
+
+		if len(framework.Content) == 0 {
+			lines = append(lines, root.Content[i].Line)
+			root.Content = append(root.Content[:i], root.Content[i+2:]...)
+		}
+		break
+	}
+
+	if len(root.Content) == 0 {
+		return nil, true, nil
+	}
+
+	if textual {
+		return removeLines(content, lines), false, nil
+	}
+
+	var buffer bytes.Buffer
+	if strings.HasPrefix(string(content), "---") {
+		buffer.WriteString("---\n")
+	}
+
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	err = encoder.Encode(&document)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return buffer.Bytes(), false, nil
+}
+
+// removeLines returns the content without the given 1-based lines.
+func removeLines(content []byte, lines []int) []byte {
+	remove := map[int]bool{}
+	for _, line := range lines {
+		remove[line] = true
+	}
+
+	var buffer bytes.Buffer
+	for i, line := range bytes.SplitAfter(content, []byte("\n")) {
+		if !remove[i+1] {
+			buffer.Write(line)
+		}
+	}
+
+	return buffer.Bytes()
+}
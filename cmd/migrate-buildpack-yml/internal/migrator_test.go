@@ -0,0 +1,239 @@
+package internal_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	dotnetcoreruntime "github.com/paketo-buildpacks/dotnet-core-runtime"
+	"github.com/paketo-buildpacks/dotnet-core-runtime/cmd/migrate-buildpack-yml/internal"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testMigrator(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir   string
+		migrator internal.Migrator
+	)
+
+	it.Before(func() {
+		var err error
+		appDir, err = os.MkdirTemp("", "app")
+		Expect(err).NotTo(HaveOccurred())
+
+		migrator = internal.NewMigrator(dotnetcoreruntime.NewBuildpackYMLParser())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(appDir)).To(Succeed())
+	})
+
+	writeFile := func(name, content string) {
+		Expect(os.WriteFile(filepath.Join(appDir, name), []byte(content), 0644)).To(Succeed())
+	}
+
+	readFile := func(name string) string {
+		content, err := os.ReadFile(filepath.Join(appDir, name))
+		Expect(err).NotTo(HaveOccurred())
+		return string(content)
+	}
+
+	context("Migrate", func() {
+		context("when buildpack.yml only sets the framework version", func() {
+			it.Before(func() {
+				writeFile("buildpack.yml", "---\ndotnet-framework:\n  version: \"6.0.*\"\n")
+			})
+
+			it("creates project.toml and removes buildpack.yml", func() {
+				changes, err := migrator.Migrate(appDir, false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changes).To(Equal(internal.Changes{
+					Version:             "6.0.*",
+					ProjectTOMLUpdated:  true,
+					BuildpackYMLRemoved: true,
+				}))
+
+				Expect(readFile("project.toml")).To(Equal(`[[build.env]]
+  name = "BP_DOTNET_FRAMEWORK_VERSION"
+  value = "6.0.*"
+`))
+				Expect(filepath.Join(appDir, "buildpack.yml")).NotTo(BeAnExistingFile())
+			})
+		})
+
+		context("when buildpack.yml has settings for other buildpacks", func() {
+			it.Before(func() {
+				writeFile("buildpack.yml", `---
+# build settings
+dotnet-framework:
+  version: "6.0.*"
+  other: setting # keep me
+dotnet-build:
+  project-path:   src/app
+`)
+				writeFile("project.toml", `# deployment settings
+[[build.env]]
+  name = "BP_LOG_LEVEL"
+  value = "DEBUG"`)
+			})
+
+			it("appends to project.toml and keeps the other keys and comments", func() {
+				changes, err := migrator.Migrate(appDir, false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changes).To(Equal(internal.Changes{
+					Version:             "6.0.*",
+					ProjectTOMLUpdated:  true,
+					BuildpackYMLUpdated: true,
+				}))
+
+				Expect(readFile("project.toml")).To(Equal(`# deployment settings
+[[build.env]]
+  name = "BP_LOG_LEVEL"
+  value = "DEBUG"
+
+[[build.env]]
+  name = "BP_DOTNET_FRAMEWORK_VERSION"
+  value = "6.0.*"
+`))
+				Expect(readFile("buildpack.yml")).To(Equal(`---
+# build settings
+dotnet-framework:
+  other: setting # keep me
+dotnet-build:
+  project-path:   src/app
+`))
+			})
+		})
+
+		context("when buildpack.yml uses flow style", func() {
+			it.Before(func() {
+				writeFile("buildpack.yml", "dotnet-framework: {version: 6.0.*}\ndotnet-build: {project-path: src/app}\n")
+			})
+
+			it("re-encodes the remaining keys", func() {
+				_, err := migrator.Migrate(appDir, false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(readFile("buildpack.yml")).To(Equal("dotnet-build: {project-path: src/app}\n"))
+			})
+		})
+
+		context("when project.toml uses schema version 0.2", func() {
+			it.Before(func() {
+				writeFile("buildpack.yml", "dotnet-framework:\n  version: 7.0.1\n")
+				writeFile("project.toml", "[_]\nschema-version = \"0.2\"\n")
+			})
+
+			it("adds the variable to the io.buildpacks.build.env table", func() {
+				_, err := migrator.Migrate(appDir, false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(readFile("project.toml")).To(Equal(`[_]
+schema-version = "0.2"
+
+[[io.buildpacks.build.env]]
+  name = "BP_DOTNET_FRAMEWORK_VERSION"
+  value = "7.0.1"
+`))
+			})
+		})
+
+		context("when project.toml already sets the same version", func() {
+			it.Before(func() {
+				writeFile("buildpack.yml", "dotnet-framework:\n  version: 7.0.1\n")
+				writeFile("project.toml", "[[build.env]]\nname = \"BP_DOTNET_FRAMEWORK_VERSION\"\nvalue = \"7.0.1\"\n")
+			})
+
+			it("leaves project.toml unchanged", func() {
+				changes, err := migrator.Migrate(appDir, false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changes.ProjectTOMLUpdated).To(BeFalse())
+				Expect(changes.BuildpackYMLRemoved).To(BeTrue())
+				Expect(readFile("project.toml")).To(Equal("[[build.env]]\nname = \"BP_DOTNET_FRAMEWORK_VERSION\"\nvalue = \"7.0.1\"\n"))
+			})
+		})
+
+		context("when buildpack.yml does not set a version", func() {
+			it.Before(func() {
+				writeFile("buildpack.yml", "dotnet-build:\n  project-path: src/app\n")
+			})
+
+			it("changes nothing", func() {
+				changes, err := migrator.Migrate(appDir, false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changes).To(Equal(internal.Changes{}))
+				Expect(filepath.Join(appDir, "project.toml")).NotTo(BeAnExistingFile())
+			})
+		})
+
+		context("when running as a dry run", func() {
+			it.Before(func() {
+				writeFile("buildpack.yml", "dotnet-framework:\n  version: 6.0.*\n")
+			})
+
+			it("reports the changes without writing them", func() {
+				changes, err := migrator.Migrate(appDir, true)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changes.ProjectTOMLUpdated).To(BeTrue())
+				Expect(changes.BuildpackYMLRemoved).To(BeTrue())
+
+				Expect(filepath.Join(appDir, "project.toml")).NotTo(BeAnExistingFile())
+				Expect(filepath.Join(appDir, "buildpack.yml")).To(BeARegularFile())
+			})
+		})
+
+		context("failure cases", func() {
+			context("when project.toml sets a different version", func() {
+				it.Before(func() {
+					writeFile("buildpack.yml", "dotnet-framework:\n  version: 6.0.*\n")
+					writeFile("project.toml", "[[build.env]]\nname = \"BP_DOTNET_FRAMEWORK_VERSION\"\nvalue = \"7.0.*\"\n")
+				})
+
+				it("returns an error and changes nothing", func() {
+					_, err := migrator.Migrate(appDir, false)
+					Expect(err).To(MatchError(ContainSubstring(`BP_DOTNET_FRAMEWORK_VERSION is already set to "7.0.*", which differs from "6.0.*" in buildpack.yml`)))
+					Expect(readFile("buildpack.yml")).To(Equal("dotnet-framework:\n  version: 6.0.*\n"))
+				})
+			})
+
+			context("when project.toml sets the build environment inline", func() {
+				it.Before(func() {
+					writeFile("buildpack.yml", "dotnet-framework:\n  version: 6.0.*\n")
+					writeFile("project.toml", "[build]\nenv = [{name = \"BP_LOG_LEVEL\", value = \"DEBUG\"}]\n")
+				})
+
+				it("returns an error and changes nothing", func() {
+					_, err := migrator.Migrate(appDir, false)
+					Expect(err).To(MatchError(ContainSubstring(`build.env is defined inline, add BP_DOTNET_FRAMEWORK_VERSION = "6.0.*" to it by hand`)))
+					Expect(readFile("project.toml")).To(Equal("[build]\nenv = [{name = \"BP_LOG_LEVEL\", value = \"DEBUG\"}]\n"))
+					Expect(readFile("buildpack.yml")).To(Equal("dotnet-framework:\n  version: 6.0.*\n"))
+				})
+			})
+
+			context("when project.toml is malformed", func() {
+				it.Before(func() {
+					writeFile("buildpack.yml", "dotnet-framework:\n  version: 6.0.*\n")
+					writeFile("project.toml", "[[build.env")
+				})
+
+				it("returns an error", func() {
+					_, err := migrator.Migrate(appDir, false)
+					Expect(err).To(MatchError(ContainSubstring("failed to update")))
+				})
+			})
+
+			context("when buildpack.yml is malformed", func() {
+				it.Before(func() {
+					writeFile("buildpack.yml", "%%%")
+				})
+
+				it("returns an error", func() {
+					_, err := migrator.Migrate(appDir, false)
+					Expect(err).To(MatchError(ContainSubstring("failed to parse")))
+				})
+			})
+		})
+	})
+}
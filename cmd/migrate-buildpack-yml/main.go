@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	dotnetcoreruntime "github.com/paketo-buildpacks/dotnet-core-runtime"
+	"github.com/paketo-buildpacks/dotnet-core-runtime/cmd/migrate-buildpack-yml/internal"
+)
+
+// migrate-buildpack-yml moves the deprecated dotnet-framework.version setting
+// from an application's buildpack.yml into a BP_DOTNET_FRAMEWORK_VERSION
+// build environment variable in its project.toml.
+//
+// Usage:
+//
+//	migrate-buildpack-yml --app <app-dir> [--dry-run]
+func main() {
+	var (
+		appDir string
+		dryRun bool
+	)
+
+	flag.StringVar(&appDir, "app", ".", "path to the application directory")
+	flag.BoolVar(&dryRun, "dry-run", false, "report the changes without writing them")
+	flag.Parse()
+
+	changes, err := internal.NewMigrator(dotnetcoreruntime.NewBuildpackYMLParser()).Migrate(appDir, dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to migrate buildpack.yml: %s\n", err)
+		os.Exit(1)
+	}
+
+	if changes.Version == "" {
+		fmt.Println("buildpack.yml does not set dotnet-framework.version; nothing to migrate")
+		return
+	}
+
+	set, remove := "Set", "Removed"
+	if dryRun {
+		set, remove = "Would set", "Would remove"
+	}
+
+	if changes.ProjectTOMLUpdated {
+		fmt.Printf("%s %s = %q in project.toml\n", set, internal.EnvironmentVariable, changes.Version)
+	} else {
+		fmt.Printf("project.toml already sets %s = %q\n", internal.EnvironmentVariable, changes.Version)
+	}
+
+	if changes.BuildpackYMLRemoved {
+		fmt.Printf("%s buildpack.yml, which has no other settings\n", remove)
+	}
+
+	if changes.BuildpackYMLUpdated {
+		fmt.Printf("%s dotnet-framework.version from buildpack.yml\n", remove)
+	}
+}
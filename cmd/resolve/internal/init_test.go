@@ -0,0 +1,15 @@
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitResolve(t *testing.T) {
+	suite := spec.New("resolve", spec.Report(report.Terminal{}))
+	suite("Flags", testFlags)
+	suite("Resolver", testResolver)
+	suite.Run(t)
+}
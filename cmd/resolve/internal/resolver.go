@@ -0,0 +1,197 @@
+package internal
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	dotnetcoreruntime "github.com/paketo-buildpacks/dotnet-core-runtime"
+	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/packit/v2/chronos"
+	"github.com/paketo-buildpacks/packit/v2/draft"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+// Options are the inputs of a resolution, as given on the command line.
+type Options struct {
+	AppDir        string
+	Stack         string
+	BuildpackTOML string
+	PlatformDir   string
+	Env           []string
+}
+
+type envFlags []string
+
+func (e *envFlags) String() string {
+	return strings.Join(*e, ",")
+}
+
+func (e *envFlags) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("expected KEY=VALUE, got %q", value)
+	}
+	*e = append(*e, value)
+	return nil
+}
+
+// ParseFlags parses the command line arguments of the resolve command. Usage
+// and parsing errors are written to output.
+func ParseFlags(args []string, output io.Writer) (Options, error) {
+	var (
+		options Options
+		env     envFlags
+	)
+
+	flags := flag.NewFlagSet("resolve", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.StringVar(&options.AppDir, "app", ".", "path to the application directory")
+	flags.StringVar(&options.Stack, "stack", "io.buildpacks.stacks.jammy", "stack ID to resolve the runtime for")
+	flags.StringVar(&options.BuildpackTOML, "buildpack-toml", "", "path to buildpack.toml (defaults to the one bundled with this command)")
+	flags.StringVar(&options.PlatformDir, "platform", "", "path to a platform directory whose bindings may supply a dependency catalog")
+	flags.Var(&env, "env", "build-time environment variable in the form KEY=VALUE (may be repeated)")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return Options{}, err
+	}
+
+	if flags.NArg() > 0 {
+		err = fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+		fmt.Fprintln(output, err)
+		return Options{}, err
+	}
+
+	options.Env = env
+
+	return options, nil
+}
+
+type Resolver struct {
+	output io.Writer
+	clock  chronos.Clock
+}
+
+func NewResolver(output io.Writer, clock chronos.Clock) Resolver {
+	return Resolver{
+		output: output,
+		clock:  clock,
+	}
+}
+
+// Resolve runs detection against the application and selects the .NET Core
+// Runtime that a build would install, logging the candidates and the
+// selection to the output of the resolver. The environment variables of the
+// options are only set for the duration of the resolution.
+func (r Resolver) Resolve(options Options) (postal.Dependency, error) {
+	var dependency postal.Dependency
+	err := withEnvironment(options.Env, func() error {
+		var err error
+		dependency, err = r.resolve(options)
+		return err
+	})
+
+	return dependency, err
+}
+
+func (r Resolver) resolve(options Options) (postal.Dependency, error) {
+	appDir, err := filepath.Abs(options.AppDir)
+	if err != nil {
+		return postal.Dependency{}, err
+	}
+
+	buildpackTOML := options.BuildpackTOML
+	if buildpackTOML == "" {
+		executable, err := os.Executable()
+		if err != nil {
+			return postal.Dependency{}, err
+		}
+		buildpackTOML = filepath.Join(filepath.Dir(executable), "..", "buildpack.toml")
+	}
+
+	if _, err := os.Stat(buildpackTOML); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return postal.Dependency{}, fmt.Errorf("could not find buildpack.toml at %s; use --buildpack-toml to specify its location", buildpackTOML)
+		}
+		return postal.Dependency{}, err
+	}
+
+	logger := scribe.NewEmitter(r.output).WithLevel("DEBUG")
+
+	detect := dotnetcoreruntime.Detect(dotnetcoreruntime.NewBuildpackYMLParser(), dotnetcoreruntime.NewVersionFileParser(), dotnetcoreruntime.NewToolVersionsParser(logger), dotnetcoreruntime.NewGlobalJSONParser(), dotnetcoreruntime.NewDepsJSONParser(), dotnetcoreruntime.NewProjectFileParser(), logger)
+	result, err := detect(packit.DetectContext{
+		WorkingDir: appDir,
+		CNBPath:    filepath.Dir(buildpackTOML),
+		Stack:      options.Stack,
+	})
+	if err != nil {
+		return postal.Dependency{}, err
+	}
+
+	var entries []packit.BuildpackPlanEntry
+	for _, requirement := range result.Plan.Requires {
+		metadata, _ := requirement.Metadata.(map[string]interface{})
+		entries = append(entries, packit.BuildpackPlanEntry{
+			Name:     requirement.Name,
+			Metadata: metadata,
+		})
+	}
+
+	logger.Title("Resolving .NET Core Runtime for %s (stack %s)", appDir, options.Stack)
+
+	entry := packit.BuildpackPlanEntry{
+		Name: "dotnet-runtime",
+		Metadata: map[string]interface{}{
+			"version-source": "default-versions",
+		},
+	}
+
+	if len(entries) > 0 {
+		var sortedEntries []packit.BuildpackPlanEntry
+		entry, sortedEntries = draft.NewPlanner().Resolve("dotnet-runtime", entries, dotnetcoreruntime.Priorities)
+		logger.Candidates(sortedEntries)
+	} else {
+		logger.Subprocess("No version requirements found; using the default version from buildpack.toml")
+		logger.Break()
+	}
+
+	dependency, err := dotnetcoreruntime.NewRuntimeVersionResolver(logger).Resolve(buildpackTOML, entry, options.Stack, options.PlatformDir)
+	if err != nil {
+		return postal.Dependency{}, err
+	}
+
+	logger.SelectedDependency(entry, dependency, r.clock.Now())
+
+	logger.Process("Selected .NET Core Runtime %s", dependency.Version)
+	logger.Subprocess("URI: %s", dependency.URI)
+	logger.Subprocess("SHA256: %s", dependency.SHA256) //nolint:staticcheck
+
+	return dependency, nil
+}
+
+// withEnvironment sets the given KEY=VALUE variables while f runs and restores
+// their previous values afterwards.
+func withEnvironment(env []string, f func() error) error {
+	for _, variable := range env {
+		key, value, _ := strings.Cut(variable, "=")
+
+		previous, ok := os.LookupEnv(key)
+		err := os.Setenv(key, value)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			defer os.Setenv(key, previous)
+		} else {
+			defer os.Unsetenv(key)
+		}
+	}
+
+	return f()
+}
@@ -0,0 +1,217 @@
+package internal_test
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/dotnet-core-runtime/cmd/resolve/internal"
+	"github.com/paketo-buildpacks/packit/v2/chronos"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testFlags(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		output *bytes.Buffer
+	)
+
+	it.Before(func() {
+		output = bytes.NewBuffer(nil)
+	})
+
+	it("uses defaults for the flags that are not given", func() {
+		options, err := internal.ParseFlags(nil, output)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(options).To(Equal(internal.Options{
+			AppDir: ".",
+			Stack:  "io.buildpacks.stacks.jammy",
+		}))
+	})
+
+	it("parses every flag", func() {
+		options, err := internal.ParseFlags([]string{
+			"--app", "some-app",
+			"--stack", "some-stack",
+			"--buildpack-toml", "some-buildpack.toml",
+			"--platform", "some-platform",
+			"--env", "BP_DOTNET_FRAMEWORK_VERSION=6.0.*",
+			"--env", "BP_DOTNET_PROJECT_PATH=src/app",
+		}, output)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(options).To(Equal(internal.Options{
+			AppDir:        "some-app",
+			Stack:         "some-stack",
+			BuildpackTOML: "some-buildpack.toml",
+			PlatformDir:   "some-platform",
+			Env:           []string{"BP_DOTNET_FRAMEWORK_VERSION=6.0.*", "BP_DOTNET_PROJECT_PATH=src/app"},
+		}))
+	})
+
+	context("failure cases", func() {
+		context("when an --env value is not in the form KEY=VALUE", func() {
+			it("returns an error", func() {
+				_, err := internal.ParseFlags([]string{"--env", "BP_DOTNET_FRAMEWORK_VERSION"}, output)
+				Expect(err).To(MatchError(ContainSubstring(`expected KEY=VALUE, got "BP_DOTNET_FRAMEWORK_VERSION"`)))
+				Expect(output.String()).To(ContainSubstring("Usage of resolve"))
+			})
+		})
+
+		context("when there are positional arguments", func() {
+			it("returns an error", func() {
+				_, err := internal.ParseFlags([]string{"--app", "some-app", "extra"}, output)
+				Expect(err).To(MatchError("unexpected arguments: extra"))
+			})
+		})
+
+		context("when help is requested", func() {
+			it("returns flag.ErrHelp", func() {
+				_, err := internal.ParseFlags([]string{"--help"}, output)
+				Expect(err).To(MatchError(flag.ErrHelp))
+			})
+		})
+	})
+}
+
+func testResolver(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appDir        string
+		cnbDir        string
+		buildpackTOML string
+		output        *bytes.Buffer
+		resolver      internal.Resolver
+	)
+
+	it.Before(func() {
+		var err error
+		appDir, err = os.MkdirTemp("", "app")
+		Expect(err).NotTo(HaveOccurred())
+
+		cnbDir, err = os.MkdirTemp("", "cnb")
+		Expect(err).NotTo(HaveOccurred())
+
+		buildpackTOML = filepath.Join(cnbDir, "buildpack.toml")
+		Expect(os.WriteFile(buildpackTOML, []byte(`api = "0.7"
+[buildpack]
+  id = "org.some-org.some-buildpack"
+  name = "Some Buildpack"
+  version = "1.2.3"
+
+[metadata]
+  [metadata.default-versions]
+    dotnet-runtime = "6.0.*"
+
+  [[metadata.dependencies]]
+    id = "dotnet-runtime"
+    sha256 = "some-sha-6"
+    stacks = ["some-stack"]
+    uri = "some-uri-6"
+    version = "6.0.13"
+
+  [[metadata.dependencies]]
+    id = "dotnet-runtime"
+    sha256 = "some-sha-7"
+    stacks = ["some-stack"]
+    uri = "some-uri-7"
+    version = "7.0.2"
+`), 0600)).To(Succeed())
+
+		output = bytes.NewBuffer(nil)
+		resolver = internal.NewResolver(output, chronos.DefaultClock)
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(appDir)).To(Succeed())
+		Expect(os.RemoveAll(cnbDir)).To(Succeed())
+	})
+
+	context("when the application has no version requirements", func() {
+		it("selects the default version", func() {
+			dependency, err := resolver.Resolve(internal.Options{
+				AppDir:        appDir,
+				Stack:         "some-stack",
+				BuildpackTOML: buildpackTOML,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dependency.Version).To(Equal("6.0.13"))
+
+			Expect(output.String()).To(ContainSubstring("No version requirements found; using the default version from buildpack.toml"))
+			Expect(output.String()).To(ContainSubstring("Selected .NET Core Runtime 6.0.13"))
+			Expect(output.String()).To(ContainSubstring("URI: some-uri-6"))
+			Expect(output.String()).To(ContainSubstring("SHA256: some-sha-6"))
+		})
+	})
+
+	context("when the application has a project file", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(appDir, "app.csproj"), []byte(`<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFramework>net7.0</TargetFramework>
+  </PropertyGroup>
+</Project>`), 0600)).To(Succeed())
+		})
+
+		it("selects a version that satisfies its target framework", func() {
+			dependency, err := resolver.Resolve(internal.Options{
+				AppDir:        appDir,
+				Stack:         "some-stack",
+				BuildpackTOML: buildpackTOML,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dependency.Version).To(Equal("7.0.2"))
+
+			Expect(output.String()).To(ContainSubstring("app.csproj"))
+			Expect(output.String()).To(ContainSubstring("Selected .NET Core Runtime 7.0.2"))
+		})
+	})
+
+	context("when a build environment variable is given", func() {
+		it("is applied during the resolution and restored afterwards", func() {
+			dependency, err := resolver.Resolve(internal.Options{
+				AppDir:        appDir,
+				Stack:         "some-stack",
+				BuildpackTOML: buildpackTOML,
+				Env:           []string{"BP_DOTNET_FRAMEWORK_VERSION=7.0"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dependency.Version).To(Equal("7.0.2"))
+
+			Expect(output.String()).To(ContainSubstring("BP_DOTNET_FRAMEWORK_VERSION"))
+
+			_, ok := os.LookupEnv("BP_DOTNET_FRAMEWORK_VERSION")
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	context("failure cases", func() {
+		context("when buildpack.toml does not exist", func() {
+			it("returns an error", func() {
+				_, err := resolver.Resolve(internal.Options{
+					AppDir:        appDir,
+					Stack:         "some-stack",
+					BuildpackTOML: filepath.Join(cnbDir, "missing.toml"),
+				})
+				Expect(err).To(MatchError(ContainSubstring("could not find buildpack.toml at")))
+				Expect(err).To(MatchError(ContainSubstring("use --buildpack-toml to specify its location")))
+			})
+		})
+
+		context("when no dependency matches the stack", func() {
+			it("returns an error", func() {
+				_, err := resolver.Resolve(internal.Options{
+					AppDir:        appDir,
+					Stack:         "other-stack",
+					BuildpackTOML: buildpackTOML,
+				})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/paketo-buildpacks/dotnet-core-runtime/cmd/resolve/internal"
+	"github.com/paketo-buildpacks/packit/v2/chronos"
+)
+
+// resolve previews the .NET Core Runtime that the buildpack would select for
+// an application, without running a build.
+//
+// Usage:
+//
+//	resolve --app <app-dir> --stack <stack-id> [--env KEY=VALUE]... [--buildpack-toml <path>] [--platform <platform-dir>]
+func main() {
+	options, err := internal.ParseFlags(os.Args[1:], os.Stderr)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		os.Exit(2)
+	}
+
+	_, err = internal.NewResolver(os.Stdout, chronos.DefaultClock).Resolve(options)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to resolve runtime: %s\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,15 @@
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitUpdateDependencies(t *testing.T) {
+	suite := spec.New("update-dependencies", spec.Report(report.Terminal{}))
+	suite("ParseReleases", testParseReleases)
+	suite("Updater", testUpdater)
+	suite.Run(t)
+}
@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Release is a .NET Core Runtime release, as described by Microsoft's
+// releases.json, for a single runtime identifier.
+type Release struct {
+	Version         string
+	URL             string
+	SHA512          string
+	DeprecationDate *time.Time
+}
+
+type channelReleases struct {
+	ChannelVersion string `json:"channel-version"`
+	EOLDate        string `json:"eol-date"`
+	Releases       []struct {
+		Runtime *struct {
+			Version string `json:"version"`
+			Files   []struct {
+				Name string `json:"name"`
+				RID  string `json:"rid"`
+				URL  string `json:"url"`
+				Hash string `json:"hash"`
+			} `json:"files"`
+		} `json:"runtime"`
+	} `json:"releases"`
+}
+
+type releasesIndex struct {
+	ReleasesIndex []struct {
+		ChannelVersion string `json:"channel-version"`
+		EOLDate        string `json:"eol-date"`
+	} `json:"releases-index"`
+}
+
+// ParseReleases reads the runtime releases for the given runtime identifier
+// from one or more channel releases.json files. When an indexPath to a
+// releases-index.json is given, its end-of-life dates take precedence over the
+// ones recorded in the channel files.
+func ParseReleases(paths []string, indexPath, rid string) ([]Release, error) {
+	eolDates := map[string]string{}
+	if indexPath != "" {
+		var index releasesIndex
+		err := decodeJSONFile(indexPath, &index)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, channel := range index.ReleasesIndex {
+			eolDates[channel.ChannelVersion] = channel.EOLDate
+		}
+	}
+
+	var releases []Release
+	for _, path := range paths {
+		var channel channelReleases
+		err := decodeJSONFile(path, &channel)
+		if err != nil {
+			return nil, err
+		}
+
+		eolDate, ok := eolDates[channel.ChannelVersion]
+		if !ok {
+			eolDate = channel.EOLDate
+		}
+
+		var deprecationDate *time.Time
+		if eolDate != "" {
+			date, err := time.Parse("2006-01-02", eolDate)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse eol-date %q for channel %s: %w", eolDate, channel.ChannelVersion, err)
+			}
+			deprecationDate = &date
+		}
+
+		for _, release := range channel.Releases {
+			if release.Runtime == nil || release.Runtime.Version == "" {
+				continue
+			}
+
+			for _, file := range release.Runtime.Files {
+				if file.RID != rid || !strings.HasSuffix(file.Name, ".tar.gz") {
+					continue
+				}
+
+				releases = append(releases, Release{
+					Version:         release.Runtime.Version,
+					URL:             file.URL,
+					SHA512:          strings.ToLower(file.Hash),
+					DeprecationDate: deprecationDate,
+				})
+			}
+		}
+	}
+
+	return releases, nil
+}
+
+func decodeJSONFile(path string, v interface{}) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	err = json.NewDecoder(file).Decode(v)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package internal_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/paketo-buildpacks/dotnet-core-runtime/cmd/update-dependencies/internal"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testParseReleases(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		dir string
+	)
+
+	it.Before(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "releases")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.WriteFile(filepath.Join(dir, "releases.json"), []byte(`{
+  "channel-version": "6.0",
+  "eol-date": "2024-11-12",
+  "releases": [
+    {
+      "release-version": "6.0.13",
+      "runtime": {
+        "version": "6.0.13",
+        "files": [
+          {
+            "name": "dotnet-runtime-linux-arm64.tar.gz",
+            "rid": "linux-arm64",
+            "url": "https://example.com/dotnet-runtime-6.0.13-linux-arm64.tar.gz",
+            "hash": "ARM64HASH"
+          },
+          {
+            "name": "dotnet-runtime-linux-x64.tar.gz",
+            "rid": "linux-x64",
+            "url": "https://example.com/dotnet-runtime-6.0.13-linux-x64.tar.gz",
+            "hash": "X64HASH"
+          },
+          {
+            "name": "dotnet-runtime-linux-x64.zip",
+            "rid": "linux-x64",
+            "url": "https://example.com/dotnet-runtime-6.0.13-linux-x64.zip",
+            "hash": "ZIPHASH"
+          }
+        ]
+      }
+    },
+    {
+      "release-version": "6.0.12-sdk-only"
+    }
+  ]
+}`), 0600)).To(Succeed())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	it("returns the runtime releases for the given runtime identifier", func() {
+		releases, err := internal.ParseReleases([]string{filepath.Join(dir, "releases.json")}, "", "linux-x64")
+		Expect(err).NotTo(HaveOccurred())
+
+		deprecationDate := time.Date(2024, 11, 12, 0, 0, 0, 0, time.UTC)
+		Expect(releases).To(Equal([]internal.Release{
+			{
+				Version:         "6.0.13",
+				URL:             "https://example.com/dotnet-runtime-6.0.13-linux-x64.tar.gz",
+				SHA512:          "x64hash",
+				DeprecationDate: &deprecationDate,
+			},
+		}))
+	})
+
+	context("when a releases-index.json is given", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(dir, "releases-index.json"), []byte(`{
+  "releases-index": [
+    {
+      "channel-version": "6.0",
+      "eol-date": "2025-01-01"
+    }
+  ]
+}`), 0600)).To(Succeed())
+		})
+
+		it("uses the end-of-life date from the index", func() {
+			releases, err := internal.ParseReleases([]string{filepath.Join(dir, "releases.json")}, filepath.Join(dir, "releases-index.json"), "linux-x64")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(releases).To(HaveLen(1))
+			Expect(*releases[0].DeprecationDate).To(Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)))
+		})
+	})
+
+	context("failure cases", func() {
+		context("when a releases file is malformed", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(dir, "releases.json"), []byte("%%%"), 0600)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := internal.ParseReleases([]string{filepath.Join(dir, "releases.json")}, "", "linux-x64")
+				Expect(err).To(MatchError(ContainSubstring("failed to parse")))
+			})
+		})
+
+		context("when the eol-date is malformed", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(dir, "releases.json"), []byte(`{"channel-version": "6.0", "eol-date": "soon"}`), 0600)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := internal.ParseReleases([]string{filepath.Join(dir, "releases.json")}, "", "linux-x64")
+				Expect(err).To(MatchError(ContainSubstring(`failed to parse eol-date "soon" for channel 6.0`)))
+			})
+		})
+
+		context("when the releases file does not exist", func() {
+			it("returns an error", func() {
+				_, err := internal.ParseReleases([]string{filepath.Join(dir, "missing.json")}, "", "linux-x64")
+				Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+			})
+		})
+	})
+}
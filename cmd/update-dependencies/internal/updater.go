@@ -0,0 +1,293 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+)
+
+const DependencyID = "dotnet-runtime"
+
+type Transport interface {
+	Drop(root, uri string) (io.ReadCloser, error)
+}
+
+// Changes lists the dependency versions that an update added, removed and
+// refreshed from upstream.
+type Changes struct {
+	Added   []string
+	Removed []string
+	Updated []Update
+}
+
+// Update names the fields of an existing dependency entry that differed from
+// the upstream release and were refreshed.
+type Update struct {
+	Version string
+	Fields  []string
+}
+
+type Updater struct {
+	transport Transport
+}
+
+func NewUpdater(transport Transport) Updater {
+	return Updater{transport: transport}
+}
+
+// Update adds the newest releases matching each dotnet-runtime dependency
+// constraint to the buildpack configuration and prunes the entries that fall
+// outside of the constraint's patch budget. Entries that are kept are
+// refreshed from their upstream release, so that corrected deprecation dates
+// and locations are picked up. Dependencies that do not match any
+// constraint are left untouched.
+func (u Updater) Update(config cargo.Config, releases []Release, stacks []string) (cargo.Config, Changes, error) {
+	existing := map[string]cargo.ConfigMetadataDependency{}
+	for _, dependency := range config.Metadata.Dependencies {
+		if dependency.ID == DependencyID {
+			existing[dependency.Version] = dependency
+		}
+	}
+
+	available := map[string]Release{}
+	for _, release := range releases {
+		version, err := semver.NewVersion(release.Version)
+		if err != nil || version.Prerelease() != "" {
+			continue
+		}
+		available[release.Version] = release
+	}
+
+	versions := map[string]bool{}
+	for version := range existing {
+		versions[version] = true
+	}
+	for version := range available {
+		versions[version] = true
+	}
+
+	keep := map[string]bool{}
+	constrained := map[string]bool{}
+	for _, constraint := range config.Metadata.DependencyConstraints {
+		if constraint.ID != DependencyID {
+			continue
+		}
+
+		c, err := semver.NewConstraint(constraint.Constraint)
+		if err != nil {
+			return cargo.Config{}, Changes{}, fmt.Errorf("failed to parse dependency constraint %q: %w", constraint.Constraint, err)
+		}
+
+		var candidates []*semver.Version
+		for v := range versions {
+			version, err := semver.NewVersion(v)
+			if err != nil {
+				return cargo.Config{}, Changes{}, err
+			}
+
+			if c.Check(version) {
+				constrained[v] = true
+				candidates = append(candidates, version)
+			}
+		}
+
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].GreaterThan(candidates[j])
+		})
+
+		for i, version := range candidates {
+			if i >= constraint.Patches {
+				break
+			}
+			keep[version.Original()] = true
+		}
+	}
+
+	var (
+		changes      Changes
+		dependencies []cargo.ConfigMetadataDependency
+	)
+
+	for _, dependency := range config.Metadata.Dependencies {
+		if dependency.ID == DependencyID && constrained[dependency.Version] && !keep[dependency.Version] {
+			changes.Removed = append(changes.Removed, dependency.Version)
+			continue
+		}
+
+		if release, ok := available[dependency.Version]; ok && dependency.ID == DependencyID && keep[dependency.Version] {
+			refreshed, fields, err := u.refresh(dependency, release)
+			if err != nil {
+				return cargo.Config{}, Changes{}, err
+			}
+
+			if len(fields) > 0 {
+				dependency = refreshed
+				changes.Updated = append(changes.Updated, Update{Version: dependency.Version, Fields: fields})
+			}
+		}
+
+		dependencies = append(dependencies, dependency)
+	}
+
+	for version := range keep {
+		if _, ok := existing[version]; ok {
+			continue
+		}
+
+		dependency, err := u.newDependency(available[version], stacks)
+		if err != nil {
+			return cargo.Config{}, Changes{}, err
+		}
+
+		dependencies = append(dependencies, dependency)
+		changes.Added = append(changes.Added, version)
+	}
+
+	sort.SliceStable(dependencies, func(i, j int) bool {
+		if dependencies[i].ID != dependencies[j].ID {
+			return dependencies[i].ID < dependencies[j].ID
+		}
+
+		iVersion, iErr := semver.NewVersion(dependencies[i].Version)
+		jVersion, jErr := semver.NewVersion(dependencies[j].Version)
+		if iErr != nil || jErr != nil {
+			return dependencies[i].Version < dependencies[j].Version
+		}
+		return iVersion.LessThan(jVersion)
+	})
+
+	sortVersions(changes.Added)
+	sortVersions(changes.Removed)
+	sort.Slice(changes.Updated, func(i, j int) bool {
+		return semver.MustParse(changes.Updated[i].Version).LessThan(semver.MustParse(changes.Updated[j].Version))
+	})
+
+	config.Metadata.Dependencies = dependencies
+
+	return config, changes, nil
+}
+
+func (u Updater) newDependency(release Release, stacks []string) (cargo.ConfigMetadataDependency, error) {
+	sourceSHA256, err := u.checksum(release)
+	if err != nil {
+		return cargo.ConfigMetadataDependency{}, err
+	}
+
+	return cargo.ConfigMetadataDependency{
+		CPE:             fmt.Sprintf("cpe:2.3:a:microsoft:.net:%s:*:*:*:*:*:*:*", release.Version),
+		PURL:            purl(release.Version, sourceSHA256, release.URL),
+		DeprecationDate: release.DeprecationDate,
+		ID:              DependencyID,
+		Licenses:        []interface{}{"MIT", "MIT-0"},
+		Name:            ".NET Core Runtime",
+		SHA256:          sourceSHA256,
+		Source:          release.URL,
+		SourceSHA256:    sourceSHA256,
+		Stacks:          stacks,
+		URI:             release.URL,
+		Version:         release.Version,
+	}, nil
+}
+
+// refresh returns the dependency with the fields that describe its upstream
+// release brought up to date, along with the names of the fields that
+// changed. The artifact is only downloaded when its location changed or the
+// entry records no source checksum, since Microsoft publishes a SHA512 that
+// cannot be compared with the recorded SHA256. The uri and sha256 are only
+// refreshed for entries that point at the upstream artifact; those of
+// artifacts repackaged by Paketo are kept.
+func (u Updater) refresh(dependency cargo.ConfigMetadataDependency, release Release) (cargo.ConfigMetadataDependency, []string, error) {
+	var fields []string
+	set := func(field string, value *string, updated string) {
+		if *value != updated {
+			*value = updated
+			fields = append(fields, field)
+		}
+	}
+
+	refreshed := dependency
+
+	if !sameDate(dependency.DeprecationDate, release.DeprecationDate) {
+		refreshed.DeprecationDate = release.DeprecationDate
+		fields = append(fields, "deprecation_date")
+	}
+
+	if dependency.Source == release.URL && (dependency.SourceSHA256 != "" || dependency.SourceChecksum != "") {
+		return refreshed, fields, nil
+	}
+
+	sourceSHA256, err := u.checksum(release)
+	if err != nil {
+		return cargo.ConfigMetadataDependency{}, nil, err
+	}
+
+	if dependency.URI == dependency.Source {
+		set("uri", &refreshed.URI, release.URL)
+		if dependency.Checksum != "" {
+			set("checksum", &refreshed.Checksum, "sha256:"+sourceSHA256)
+		} else {
+			set("sha256", &refreshed.SHA256, sourceSHA256)
+		}
+	}
+
+	set("source", &refreshed.Source, release.URL)
+	if dependency.SourceChecksum != "" {
+		set("source-checksum", &refreshed.SourceChecksum, "sha256:"+sourceSHA256)
+	} else {
+		set("source_sha256", &refreshed.SourceSHA256, sourceSHA256)
+	}
+
+	if dependency.PURL != "" {
+		set("purl", &refreshed.PURL, purl(release.Version, sourceSHA256, release.URL))
+	}
+
+	return refreshed, fields, nil
+}
+
+func sameDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func purl(version, sourceSHA256, url string) string {
+	return fmt.Sprintf("pkg:generic/dotnet-runtime@%s?checksum=%s&download_url=%s", version, sourceSHA256, url)
+}
+
+// checksum downloads the release artifact, verifies it against the SHA512
+// published by Microsoft and returns its SHA256.
+func (u Updater) checksum(release Release) (string, error) {
+	bundle, err := u.transport.Drop("", release.URL)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", release.URL, err)
+	}
+	defer bundle.Close()
+
+	sha256Hash := sha256.New()
+	sha512Hash := sha512.New()
+
+	_, err = io.Copy(io.MultiWriter(sha256Hash, sha512Hash), bundle)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", release.URL, err)
+	}
+
+	if sum := hex.EncodeToString(sha512Hash.Sum(nil)); sum != release.SHA512 {
+		return "", fmt.Errorf("checksum mismatch for %s: expected sha512 %s, got %s", release.URL, release.SHA512, sum)
+	}
+
+	return hex.EncodeToString(sha256Hash.Sum(nil)), nil
+}
+
+func sortVersions(versions []string) {
+	sort.Slice(versions, func(i, j int) bool {
+		return semver.MustParse(versions[i]).LessThan(semver.MustParse(versions[j]))
+	})
+}
@@ -0,0 +1,297 @@
+package internal_test
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/paketo-buildpacks/dotnet-core-runtime/cmd/update-dependencies/internal"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testUpdater(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		server   *httptest.Server
+		config   cargo.Config
+		releases []internal.Release
+		updater  internal.Updater
+
+		deprecationDate time.Time
+		sha256Sum       string
+	)
+
+	it.Before(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			fmt.Fprintf(w, "contents of %s", req.URL.Path)
+		}))
+
+		sha512Sum := sha512.Sum512([]byte("contents of /dotnet-runtime-6.0.14-linux-x64.tar.gz"))
+		sum := sha256.Sum256([]byte("contents of /dotnet-runtime-6.0.14-linux-x64.tar.gz"))
+		sha256Sum = hex.EncodeToString(sum[:])
+
+		deprecationDate = time.Date(2024, 11, 12, 0, 0, 0, 0, time.UTC)
+		releases = []internal.Release{
+			{
+				Version:         "6.0.11",
+				URL:             fmt.Sprintf("%s/dotnet-runtime-6.0.11-linux-x64.tar.gz", server.URL),
+				SHA512:          "some-sha512",
+				DeprecationDate: &deprecationDate,
+			},
+			{
+				Version:         "6.0.14",
+				URL:             fmt.Sprintf("%s/dotnet-runtime-6.0.14-linux-x64.tar.gz", server.URL),
+				SHA512:          hex.EncodeToString(sha512Sum[:]),
+				DeprecationDate: &deprecationDate,
+			},
+			{
+				Version: "6.0.15-rc.1",
+				URL:     fmt.Sprintf("%s/dotnet-runtime-6.0.15-rc.1-linux-x64.tar.gz", server.URL),
+				SHA512:  "some-sha512",
+			},
+		}
+
+		config = cargo.Config{
+			Metadata: cargo.ConfigMetadata{
+				Dependencies: []cargo.ConfigMetadataDependency{
+					{ID: "dotnet-runtime", Version: "6.0.12", URI: "some-uri-6.0.12"},
+					{ID: "dotnet-runtime", Version: "7.0.1", URI: "some-uri-7.0.1"},
+					{ID: "dotnet-runtime", Version: "6.0.13", URI: "some-uri-6.0.13"},
+				},
+				DependencyConstraints: []cargo.ConfigMetadataDependencyConstraint{
+					{ID: "dotnet-runtime", Constraint: "6.0.*", Patches: 2},
+				},
+			},
+		}
+
+		updater = internal.NewUpdater(cargo.NewTransport())
+	})
+
+	it.After(func() {
+		server.Close()
+	})
+
+	it("adds the newest releases and prunes entries beyond the patch budget", func() {
+		updated, changes, err := updater.Update(config, releases, []string{"some-stack"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(changes).To(Equal(internal.Changes{
+			Added:   []string{"6.0.14"},
+			Removed: []string{"6.0.12"},
+		}))
+
+		url := fmt.Sprintf("%s/dotnet-runtime-6.0.14-linux-x64.tar.gz", server.URL)
+		Expect(updated.Metadata.Dependencies).To(Equal([]cargo.ConfigMetadataDependency{
+			{ID: "dotnet-runtime", Version: "6.0.13", URI: "some-uri-6.0.13"},
+			{
+				CPE:             "cpe:2.3:a:microsoft:.net:6.0.14:*:*:*:*:*:*:*",
+				PURL:            fmt.Sprintf("pkg:generic/dotnet-runtime@6.0.14?checksum=%s&download_url=%s", sha256Sum, url),
+				DeprecationDate: &deprecationDate,
+				ID:              "dotnet-runtime",
+				Licenses:        []interface{}{"MIT", "MIT-0"},
+				Name:            ".NET Core Runtime",
+				SHA256:          sha256Sum,
+				Source:          url,
+				SourceSHA256:    sha256Sum,
+				Stacks:          []string{"some-stack"},
+				URI:             url,
+				Version:         "6.0.14",
+			},
+			{ID: "dotnet-runtime", Version: "7.0.1", URI: "some-uri-7.0.1"},
+		}))
+	})
+
+	context("when the buildpack.toml is already up to date", func() {
+		it.Before(func() {
+			releases = releases[:1]
+		})
+
+		it("makes no changes", func() {
+			updated, changes, err := updater.Update(config, releases, []string{"some-stack"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).To(Equal(internal.Changes{}))
+			Expect(updated.Metadata.Dependencies).To(HaveLen(3))
+		})
+	})
+
+	context("when a kept entry differs from its upstream release", func() {
+		var (
+			url          string
+			sha256Sum13  string
+			correctedEOL time.Time
+		)
+
+		it.Before(func() {
+			url = fmt.Sprintf("%s/dotnet-runtime-6.0.13-linux-x64.tar.gz", server.URL)
+			sha512Sum := sha512.Sum512([]byte("contents of /dotnet-runtime-6.0.13-linux-x64.tar.gz"))
+			sum := sha256.Sum256([]byte("contents of /dotnet-runtime-6.0.13-linux-x64.tar.gz"))
+			sha256Sum13 = hex.EncodeToString(sum[:])
+
+			correctedEOL = time.Date(2024, 11, 14, 0, 0, 0, 0, time.UTC)
+			releases = append(releases, internal.Release{
+				Version:         "6.0.13",
+				URL:             url,
+				SHA512:          hex.EncodeToString(sha512Sum[:]),
+				DeprecationDate: &correctedEOL,
+			})
+		})
+
+		context("and points at the upstream artifact", func() {
+			it.Before(func() {
+				config.Metadata.Dependencies[2] = cargo.ConfigMetadataDependency{
+					ID:              "dotnet-runtime",
+					Version:         "6.0.13",
+					DeprecationDate: &deprecationDate,
+					PURL:            "pkg:generic/dotnet-runtime@6.0.13?checksum=stale-sha&download_url=some-old-url",
+					SHA256:          "stale-sha",
+					Source:          "some-old-url",
+					SourceSHA256:    "stale-sha",
+					URI:             "some-old-url",
+				}
+			})
+
+			it("refreshes the fields that differ", func() {
+				updated, changes, err := updater.Update(config, releases, []string{"some-stack"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(changes.Updated).To(Equal([]internal.Update{
+					{
+						Version: "6.0.13",
+						Fields:  []string{"deprecation_date", "uri", "sha256", "source", "source_sha256", "purl"},
+					},
+				}))
+
+				Expect(updated.Metadata.Dependencies[0]).To(Equal(cargo.ConfigMetadataDependency{
+					ID:              "dotnet-runtime",
+					Version:         "6.0.13",
+					DeprecationDate: &correctedEOL,
+					PURL:            fmt.Sprintf("pkg:generic/dotnet-runtime@6.0.13?checksum=%s&download_url=%s", sha256Sum13, url),
+					SHA256:          sha256Sum13,
+					Source:          url,
+					SourceSHA256:    sha256Sum13,
+					URI:             url,
+				}))
+			})
+		})
+
+		context("and points at an artifact repackaged by Paketo", func() {
+			it.Before(func() {
+				config.Metadata.Dependencies[2] = cargo.ConfigMetadataDependency{
+					ID:              "dotnet-runtime",
+					Version:         "6.0.13",
+					DeprecationDate: &correctedEOL,
+					SHA256:          "some-repackaged-sha",
+					Source:          "some-old-url",
+					SourceSHA256:    "stale-sha",
+					URI:             "https://deps.paketo.io/dotnet-runtime_6.0.13_some-rep.tgz",
+				}
+			})
+
+			it("only refreshes the source", func() {
+				updated, changes, err := updater.Update(config, releases, []string{"some-stack"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(changes.Updated).To(Equal([]internal.Update{
+					{Version: "6.0.13", Fields: []string{"source", "source_sha256"}},
+				}))
+
+				Expect(updated.Metadata.Dependencies[0].URI).To(Equal("https://deps.paketo.io/dotnet-runtime_6.0.13_some-rep.tgz"))
+				Expect(updated.Metadata.Dependencies[0].SHA256).To(Equal("some-repackaged-sha"))
+				Expect(updated.Metadata.Dependencies[0].Source).To(Equal(url))
+				Expect(updated.Metadata.Dependencies[0].SourceSHA256).To(Equal(sha256Sum13))
+			})
+		})
+
+		context("and its location is unchanged", func() {
+			it.Before(func() {
+				config.Metadata.Dependencies[2] = cargo.ConfigMetadataDependency{
+					ID:              "dotnet-runtime",
+					Version:         "6.0.13",
+					DeprecationDate: &deprecationDate,
+					SHA256:          "some-sha",
+					Source:          url,
+					SourceSHA256:    "some-sha",
+					URI:             url,
+				}
+
+				releases[len(releases)-1].SHA512 = "some-other-sha512"
+			})
+
+			it("refreshes the deprecation date without downloading the artifact", func() {
+				updated, changes, err := updater.Update(config, releases, []string{"some-stack"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(changes.Updated).To(Equal([]internal.Update{
+					{Version: "6.0.13", Fields: []string{"deprecation_date"}},
+				}))
+
+				Expect(updated.Metadata.Dependencies[0].DeprecationDate).To(Equal(&correctedEOL))
+				Expect(updated.Metadata.Dependencies[0].SourceSHA256).To(Equal("some-sha"))
+			})
+		})
+
+		context("and is already up to date", func() {
+			it.Before(func() {
+				config.Metadata.Dependencies[2] = cargo.ConfigMetadataDependency{
+					ID:              "dotnet-runtime",
+					Version:         "6.0.13",
+					DeprecationDate: &correctedEOL,
+					SHA256:          sha256Sum13,
+					Source:          url,
+					SourceSHA256:    sha256Sum13,
+					URI:             url,
+				}
+			})
+
+			it("does not report it", func() {
+				_, changes, err := updater.Update(config, releases, []string{"some-stack"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changes.Updated).To(BeEmpty())
+			})
+		})
+	})
+
+	context("failure cases", func() {
+		context("when a dependency constraint is invalid", func() {
+			it.Before(func() {
+				config.Metadata.DependencyConstraints[0].Constraint = "not-a-constraint"
+			})
+
+			it("returns an error", func() {
+				_, _, err := updater.Update(config, releases, nil)
+				Expect(err).To(MatchError(ContainSubstring(`failed to parse dependency constraint "not-a-constraint"`)))
+			})
+		})
+
+		context("when the downloaded artifact does not match the published checksum", func() {
+			it.Before(func() {
+				releases[1].SHA512 = "some-other-sha512"
+			})
+
+			it("returns an error", func() {
+				_, _, err := updater.Update(config, releases, nil)
+				Expect(err).To(MatchError(ContainSubstring("checksum mismatch for")))
+			})
+		})
+
+		context("when the artifact cannot be downloaded", func() {
+			it.Before(func() {
+				releases[1].URL = "%%%"
+			})
+
+			it("returns an error", func() {
+				_, _, err := updater.Update(config, releases, nil)
+				Expect(err).To(MatchError(ContainSubstring("failed to download %%%")))
+			})
+		})
+	})
+}
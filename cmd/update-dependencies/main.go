@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/paketo-buildpacks/dotnet-core-runtime/cmd/update-dependencies/internal"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+)
+
+type stringSlice []string
+
+func (s *stringSlice) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringSlice) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// update-dependencies generates or updates the dotnet-runtime entries in
+// buildpack.toml from Microsoft's releases.json files.
+//
+// Usage:
+//
+//	update-dependencies --releases <releases.json> [--releases <releases.json>]... \
+//	  [--releases-index <releases-index.json>] [--buildpack-toml <path>] [--rid linux-x64] [--stack <id>]...
+func main() {
+	var (
+		buildpackTOML string
+		releasesIndex string
+		rid           string
+		releases      stringSlice
+		stacks        stringSlice
+	)
+
+	flag.StringVar(&buildpackTOML, "buildpack-toml", "buildpack.toml", "path to the buildpack.toml to update")
+	flag.StringVar(&releasesIndex, "releases-index", "", "path to a releases-index.json providing end-of-life dates")
+	flag.StringVar(&rid, "rid", "linux-x64", "runtime identifier of the artifacts to add")
+	flag.Var(&releases, "releases", "path to a channel releases.json (may be repeated)")
+	flag.Var(&stacks, "stack", "stack ID for new entries (may be repeated; defaults to the stacks in buildpack.toml)")
+	flag.Parse()
+
+	err := run(buildpackTOML, releasesIndex, rid, releases, stacks)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to update dependencies: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(buildpackTOML, releasesIndex, rid string, releasePaths, stacks []string) error {
+	if len(releasePaths) == 0 {
+		return fmt.Errorf("at least one --releases file is required")
+	}
+
+	file, err := os.Open(buildpackTOML)
+	if err != nil {
+		return err
+	}
+
+	var config cargo.Config
+	err = cargo.DecodeConfig(file, &config)
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	if len(stacks) == 0 {
+		for _, stack := range config.Stacks {
+			stacks = append(stacks, stack.ID)
+		}
+	}
+
+	releases, err := internal.ParseReleases(releasePaths, releasesIndex, rid)
+	if err != nil {
+		return err
+	}
+
+	config, changes, err := internal.NewUpdater(cargo.NewTransport()).Update(config, releases, stacks)
+	if err != nil {
+		return err
+	}
+
+	file, err = os.Create(buildpackTOML)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	err = cargo.EncodeConfig(file, config)
+	if err != nil {
+		return err
+	}
+
+	for _, version := range changes.Added {
+		fmt.Printf("Added %s %s\n", internal.DependencyID, version)
+	}
+
+	for _, version := range changes.Removed {
+		fmt.Printf("Removed %s %s\n", internal.DependencyID, version)
+	}
+
+	for _, update := range changes.Updated {
+		fmt.Printf("Updated %s %s (%s)\n", internal.DependencyID, update.Version, strings.Join(update.Fields, ", "))
+	}
+
+	if len(changes.Added) == 0 && len(changes.Removed) == 0 && len(changes.Updated) == 0 {
+		fmt.Println("No changes")
+	}
+
+	return nil
+}
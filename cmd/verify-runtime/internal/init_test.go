@@ -0,0 +1,14 @@
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitVerifyRuntime(t *testing.T) {
+	suite := spec.New("verify-runtime", spec.Report(report.Terminal{}))
+	suite("Verifier", testVerifier)
+	suite.Run(t)
+}
@@ -0,0 +1,164 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/paketo-buildpacks/dotnet-core-runtime/internal/manifest"
+)
+
+// Verifier checks that the .NET Core Runtime linked into an application's
+// DOTNET_ROOT is intact before the application starts.
+type Verifier struct {
+	dotnetRoot   string
+	version      string
+	manifestPath string
+}
+
+func NewVerifier(dotnetRoot, version, manifestPath string) Verifier {
+	return Verifier{
+		dotnetRoot:   dotnetRoot,
+		version:      version,
+		manifestPath: manifestPath,
+	}
+}
+
+// Verify returns every problem found with the runtime installation. When
+// hashes is true, every file recorded in the build-time manifest is checked as
+// well: its size and permissions, and its checksum when the build recorded
+// one.
+func (v Verifier) Verify(hashes bool) ([]string, error) {
+	var problems []string
+
+	for _, link := range []string{"host", filepath.Join("shared", "Microsoft.NETCore.App")} {
+		if problem := v.checkLink(link); problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+
+	if v.version != "" {
+		hostfxr := filepath.Join(v.dotnetRoot, "host", "fxr", v.version, "libhostfxr.so")
+		if _, err := os.Stat(hostfxr); err != nil {
+			problems = append(problems, fmt.Sprintf("hostfxr for .NET Core Runtime %s was not found at %s", v.version, hostfxr))
+		}
+
+		framework := filepath.Join(v.dotnetRoot, "shared", "Microsoft.NETCore.App", v.version)
+		if info, err := os.Stat(framework); err != nil || !info.IsDir() {
+			problems = append(problems, fmt.Sprintf("Microsoft.NETCore.App %s was not found at %s", v.version, framework))
+		}
+	}
+
+	if hashes {
+		mismatches, err := v.checkFiles()
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, mismatches...)
+	}
+
+	return problems, nil
+}
+
+func (v Verifier) checkLink(name string) string {
+	path := filepath.Join(v.dotnetRoot, name)
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Sprintf("%s does not exist", path)
+		}
+		return fmt.Sprintf("%s cannot be read: %s", path, err)
+	}
+
+	if info.Mode()&os.ModeSymlink == 0 {
+		return ""
+	}
+
+	target, err := os.Readlink(path)
+	if err != nil {
+		return fmt.Sprintf("%s cannot be read: %s", path, err)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Sprintf("%s is a broken link to %s", path, target)
+	}
+
+	return ""
+}
+
+func (v Verifier) checkFiles() ([]string, error) {
+	if v.manifestPath == "" {
+		return nil, errors.New("no runtime manifest is available to verify file hashes against")
+	}
+
+	m, err := manifest.Read(v.manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for file := range m.Files {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	layerPath := filepath.Dir(v.manifestPath)
+
+	var problems []string
+	for _, file := range files {
+		path := filepath.Join(layerPath, filepath.FromSlash(file))
+		expected := m.Files[file]
+
+		info, err := os.Stat(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				problems = append(problems, fmt.Sprintf("%s is missing", path))
+				continue
+			}
+			return nil, err
+		}
+
+		if info.Size() != expected.Size {
+			problems = append(problems, fmt.Sprintf("%s has been modified (expected %d bytes, got %d)", path, expected.Size, info.Size()))
+			continue
+		}
+
+		if info.Mode().Perm() != expected.Mode {
+			problems = append(problems, fmt.Sprintf("%s has been modified (expected mode %s, got %s)", path, expected.Mode, info.Mode().Perm()))
+			continue
+		}
+
+		if expected.SHA256 == "" {
+			continue
+		}
+
+		sum, err := manifest.FileSHA256(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if sum != expected.SHA256 {
+			problems = append(problems, fmt.Sprintf("%s has been modified (expected sha256 %s, got %s)", path, expected.SHA256, sum))
+		}
+	}
+
+	return problems, nil
+}
+
+// Diagnostic formats the problems found by Verify into a message explaining
+// why the application cannot start.
+func Diagnostic(dotnetRoot string, problems []string) string {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "The .NET Core Runtime at DOTNET_ROOT=%s is not intact:\n", dotnetRoot)
+	for _, problem := range problems {
+		fmt.Fprintf(&builder, "  - %s\n", problem)
+	}
+	builder.WriteString("The application image may have overwritten the runtime links, or the application directory may have been moved since it was built. Rebuild the image to reinstall the runtime.")
+
+	return builder.String()
+}
@@ -0,0 +1,183 @@
+package internal_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	dotnetcoreruntime "github.com/paketo-buildpacks/dotnet-core-runtime"
+	"github.com/paketo-buildpacks/dotnet-core-runtime/cmd/verify-runtime/internal"
+	"github.com/paketo-buildpacks/dotnet-core-runtime/internal/manifest"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testVerifier(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerPath    string
+		workingDir   string
+		dotnetRoot   string
+		manifestPath string
+
+		verifier internal.Verifier
+	)
+
+	it.Before(func() {
+		var err error
+		layerPath, err = os.MkdirTemp("", "layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "working-dir")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(layerPath, "host", "fxr", "6.0.13"), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(layerPath, "host", "fxr", "6.0.13", "libhostfxr.so"), []byte("hostfxr"), 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(layerPath, "shared", "Microsoft.NETCore.App", "6.0.13"), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(layerPath, "shared", "Microsoft.NETCore.App", "6.0.13", "libcoreclr.so"), []byte("coreclr"), 0644)).To(Succeed())
+
+		dotnetRoot = filepath.Join(workingDir, ".dotnet_root")
+
+		Expect(dotnetcoreruntime.NewSymlinker().Link(dotnetRoot, layerPath)).To(Succeed())
+		Expect(manifest.Write(layerPath, "6.0.13", true)).To(Succeed())
+		manifestPath = filepath.Join(layerPath, manifest.Name)
+
+		verifier = internal.NewVerifier(dotnetRoot, "6.0.13", manifestPath)
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerPath)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("finds no problems with an intact runtime", func() {
+		problems, err := verifier.Verify(true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(problems).To(BeEmpty())
+	})
+
+	context("when a link has been removed", func() {
+		it.Before(func() {
+			Expect(os.Remove(filepath.Join(dotnetRoot, "host"))).To(Succeed())
+		})
+
+		it("reports the missing link", func() {
+			problems, err := verifier.Verify(false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(problems).To(ConsistOf(
+				fmt.Sprintf("%s does not exist", filepath.Join(dotnetRoot, "host")),
+				fmt.Sprintf("hostfxr for .NET Core Runtime 6.0.13 was not found at %s", filepath.Join(dotnetRoot, "host", "fxr", "6.0.13", "libhostfxr.so")),
+			))
+		})
+	})
+
+	context("when the links no longer resolve", func() {
+		it.Before(func() {
+			Expect(os.RemoveAll(filepath.Join(layerPath, "shared"))).To(Succeed())
+		})
+
+		it("reports the broken link", func() {
+			problems, err := verifier.Verify(false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(problems).To(ConsistOf(
+				fmt.Sprintf("%s is a broken link to %s", filepath.Join(dotnetRoot, "shared", "Microsoft.NETCore.App"), filepath.Join(layerPath, "shared", "Microsoft.NETCore.App")),
+				fmt.Sprintf("Microsoft.NETCore.App 6.0.13 was not found at %s", filepath.Join(dotnetRoot, "shared", "Microsoft.NETCore.App", "6.0.13")),
+			))
+		})
+	})
+
+	context("when a runtime file has been modified", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(layerPath, "shared", "Microsoft.NETCore.App", "6.0.13", "libcoreclr.so"), []byte("tampered"), 0644)).To(Succeed())
+		})
+
+		it("only reports the modification when hashes are verified", func() {
+			problems, err := verifier.Verify(false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(problems).To(BeEmpty())
+
+			problems, err = verifier.Verify(true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(problems).To(HaveLen(1))
+			Expect(problems[0]).To(HavePrefix(fmt.Sprintf("%s has been modified", filepath.Join(layerPath, "shared", "Microsoft.NETCore.App", "6.0.13", "libcoreclr.so"))))
+		})
+	})
+
+	context("when a runtime file has been modified without changing its size", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(layerPath, "shared", "Microsoft.NETCore.App", "6.0.13", "libcoreclr.so"), []byte("CORECLR"), 0644)).To(Succeed())
+		})
+
+		it("reports the modification by its checksum", func() {
+			problems, err := verifier.Verify(true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(problems).To(HaveLen(1))
+			Expect(problems[0]).To(HavePrefix(fmt.Sprintf("%s has been modified (expected sha256", filepath.Join(layerPath, "shared", "Microsoft.NETCore.App", "6.0.13", "libcoreclr.so"))))
+		})
+
+		context("when the build did not record checksums", func() {
+			it.Before(func() {
+				Expect(os.Remove(manifestPath)).To(Succeed())
+				Expect(manifest.Write(layerPath, "6.0.13", false)).To(Succeed())
+			})
+
+			it("only compares the size and permissions", func() {
+				problems, err := verifier.Verify(true)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(problems).To(BeEmpty())
+
+				Expect(os.Chmod(filepath.Join(layerPath, "shared", "Microsoft.NETCore.App", "6.0.13", "libcoreclr.so"), 0600)).To(Succeed())
+
+				problems, err = verifier.Verify(true)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(problems).To(ConsistOf(fmt.Sprintf("%s has been modified (expected mode -rw-r--r--, got -rw-------)", filepath.Join(layerPath, "shared", "Microsoft.NETCore.App", "6.0.13", "libcoreclr.so"))))
+			})
+		})
+	})
+
+	context("when a runtime file has been removed", func() {
+		it.Before(func() {
+			Expect(os.Remove(filepath.Join(layerPath, "host", "fxr", "6.0.13", "libhostfxr.so"))).To(Succeed())
+		})
+
+		it("reports the missing file", func() {
+			problems, err := verifier.Verify(true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(problems).To(ContainElement(fmt.Sprintf("%s is missing", filepath.Join(layerPath, "host", "fxr", "6.0.13", "libhostfxr.so"))))
+		})
+	})
+
+	context("Diagnostic", func() {
+		it("lists the problems and how to resolve them", func() {
+			Expect(internal.Diagnostic("/workspace/.dotnet_root", []string{"some-problem", "other-problem"})).To(Equal(
+				"The .NET Core Runtime at DOTNET_ROOT=/workspace/.dotnet_root is not intact:\n" +
+					"  - some-problem\n" +
+					"  - other-problem\n" +
+					"The application image may have overwritten the runtime links, or the application directory may have been moved since it was built. Rebuild the image to reinstall the runtime.",
+			))
+		})
+	})
+
+	context("failure cases", func() {
+		context("when hashes are requested without a manifest", func() {
+			it("returns an error", func() {
+				_, err := internal.NewVerifier(dotnetRoot, "6.0.13", "").Verify(true)
+				Expect(err).To(MatchError("no runtime manifest is available to verify file hashes against"))
+			})
+		})
+
+		context("when the manifest cannot be decoded", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(manifestPath, []byte("%%%"), 0644)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := verifier.Verify(true)
+				Expect(err).To(MatchError(ContainSubstring("failed to decode runtime manifest")))
+			})
+		})
+	})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/paketo-buildpacks/dotnet-core-runtime/cmd/verify-runtime/internal"
+)
+
+// verify-runtime is an exec.d binary that checks, when the container starts,
+// that DOTNET_ROOT still resolves to the .NET Core Runtime installed at build
+// time. It fails the launch with a diagnostic instead of letting the .NET host
+// fail with a less helpful error.
+//
+// It can be disabled by setting BPL_DOTNET_RUNTIME_VERIFY=false, and the
+// checksums of the runtime files can additionally be verified against the
+// build-time manifest by setting BPL_DOTNET_RUNTIME_VERIFY_HASHES=true.
+func main() {
+	err := run()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	verify, err := parseBool("BPL_DOTNET_RUNTIME_VERIFY", true)
+	if err != nil {
+		return err
+	}
+
+	hashes, err := parseBool("BPL_DOTNET_RUNTIME_VERIFY_HASHES", false)
+	if err != nil {
+		return err
+	}
+
+	dotnetRoot := os.Getenv("DOTNET_ROOT")
+	if !verify || dotnetRoot == "" {
+		return nil
+	}
+
+	verifier := internal.NewVerifier(dotnetRoot, os.Getenv("BPI_DOTNET_RUNTIME_VERSION"), os.Getenv("BPI_DOTNET_RUNTIME_MANIFEST"))
+
+	problems, err := verifier.Verify(hashes)
+	if err != nil {
+		return fmt.Errorf("failed to verify the .NET Core Runtime: %w", err)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", internal.Diagnostic(dotnetRoot, problems))
+	}
+
+	return nil
+}
+
+func parseBool(name string, defaultValue bool) (bool, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse %s value %q: %w", name, value, err)
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,388 @@
+package dotnetcoreruntime
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/Masterminds/semver"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
+)
+
+// CatalogBindingType is the type of the service bindings that supply a
+// dependency catalog overlay.
+const CatalogBindingType = "dotnet-runtime-catalog"
+
+const (
+	CatalogModeMerge   = "merge"
+	CatalogModeReplace = "replace"
+)
+
+// DependencyCatalog is an operator-supplied set of dependencies that is
+// overlaid on the dependencies in buildpack.toml, for example to install the
+// runtime from an internal artifact mirror.
+type DependencyCatalog struct {
+	// Mode is either "merge" (the default), in which the dependencies of the
+	// catalog are added to the built-in ones and replace those with the same
+	// ID, version and stacks, or "replace", in which the built-in dependencies
+	// are discarded.
+	Mode            string              `toml:"mode"`
+	DefaultVersions map[string]string   `toml:"default-versions"`
+	Dependencies    []postal.Dependency `toml:"dependencies"`
+
+	// Source describes where the catalog was read from.
+	Source string `toml:"-"`
+}
+
+// LoadDependencyCatalog reads the dependencies and default versions in the
+// buildpack.toml at the given path and applies the catalog overlays supplied
+// by the bindings of type dotnet-runtime-catalog, in the order of their names,
+// and by the file named by BP_DOTNET_RUNTIME_CATALOG. A dependency or default
+// version that is defined by more than one overlay is an error. It returns the
+// resulting metadata along with the overlays that were applied.
+func LoadDependencyCatalog(buildpackTOMLPath, platformDir string) (BuildpackTOMLMetadata, []DependencyCatalog, error) {
+	buildpackTOML, err := ParseBuildpackTOML(buildpackTOMLPath)
+	if err != nil {
+		return BuildpackTOMLMetadata{}, nil, err
+	}
+
+	overlays, err := catalogOverlays(platformDir)
+	if err != nil {
+		return BuildpackTOMLMetadata{}, nil, err
+	}
+
+	if len(overlays) == 0 {
+		return buildpackTOML.Metadata, nil, nil
+	}
+
+	metadata, err := applyCatalogOverlays(buildpackTOML.Metadata, overlays)
+	if err != nil {
+		return BuildpackTOMLMetadata{}, nil, err
+	}
+
+	return metadata, overlays, nil
+}
+
+func catalogOverlays(platformDir string) ([]DependencyCatalog, error) {
+	var overlays []DependencyCatalog
+
+	if bindingsConfigured(platformDir) {
+		bindings, err := servicebindings.NewResolver().Resolve(CatalogBindingType, "", platformDir)
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(bindings, func(i, j int) bool { return bindings[i].Name < bindings[j].Name })
+
+		for _, binding := range bindings {
+			var names []string
+			for name := range binding.Entries {
+				if name == "catalog.toml" || name == "catalog.json" {
+					names = append(names, name)
+				}
+			}
+
+			if len(names) != 1 {
+				return nil, fmt.Errorf("binding %q of type %s must contain exactly one of catalog.toml or catalog.json", binding.Name, CatalogBindingType)
+			}
+
+			catalog, err := ParseDependencyCatalog(filepath.Join(binding.Path, names[0]))
+			if err != nil {
+				return nil, err
+			}
+			catalog.Source = fmt.Sprintf("binding %q", binding.Name)
+
+			overlays = append(overlays, catalog)
+		}
+	}
+
+	if path := os.Getenv("BP_DOTNET_RUNTIME_CATALOG"); path != "" {
+		catalog, err := ParseDependencyCatalog(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read BP_DOTNET_RUNTIME_CATALOG: %w", err)
+		}
+		catalog.Source = path
+
+		overlays = append(overlays, catalog)
+	}
+
+	return overlays, nil
+}
+
+// ParseDependencyCatalog reads and validates the TOML or JSON dependency
+// catalog at the given path. The format is chosen by the file extension.
+func ParseDependencyCatalog(path string) (DependencyCatalog, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return DependencyCatalog{}, err
+	}
+
+	var catalog DependencyCatalog
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		_, err = toml.Decode(string(content), &catalog)
+
+	case ".json":
+		catalog, err = decodeJSONCatalog(content)
+
+	default:
+		return DependencyCatalog{}, fmt.Errorf("unsupported dependency catalog %s: expected a .toml or .json file", path)
+	}
+	if err != nil {
+		return DependencyCatalog{}, fmt.Errorf("failed to parse dependency catalog %s: %w", path, err)
+	}
+
+	if catalog.Mode == "" {
+		catalog.Mode = CatalogModeMerge
+	}
+
+	problems := catalog.validate()
+	if len(problems) > 0 {
+		return DependencyCatalog{}, fmt.Errorf("invalid dependency catalog %s:\n  %s", path, strings.Join(problems, "\n  "))
+	}
+
+	return catalog, nil
+}
+
+// decodeJSONCatalog decodes a JSON catalog using the same field names as the
+// TOML format by converting it to TOML first, since postal.Dependency only
+// declares TOML field names.
+func decodeJSONCatalog(content []byte) (DependencyCatalog, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	var document map[string]interface{}
+	err := decoder.Decode(&document)
+	if err != nil {
+		return DependencyCatalog{}, err
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	err = toml.NewEncoder(buffer).Encode(jsonNumbers(document))
+	if err != nil {
+		return DependencyCatalog{}, err
+	}
+
+	var catalog DependencyCatalog
+	_, err = toml.Decode(buffer.String(), &catalog)
+	if err != nil {
+		return DependencyCatalog{}, err
+	}
+
+	return catalog, nil
+}
+
+// jsonNumbers converts the json.Number values of a decoded JSON document into
+// integers or floats so that they are encoded as TOML numbers.
+func jsonNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, element := range v {
+			v[key] = jsonNumbers(element)
+		}
+	case []interface{}:
+		for i, element := range v {
+			v[i] = jsonNumbers(element)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	}
+	return value
+}
+
+func (c DependencyCatalog) validate() []string {
+	var problems []string
+
+	if c.Mode != CatalogModeMerge && c.Mode != CatalogModeReplace {
+		problems = append(problems, fmt.Sprintf("mode %q must be %q or %q", c.Mode, CatalogModeMerge, CatalogModeReplace))
+	}
+
+	ids := make([]string, 0, len(c.DefaultVersions))
+	for id := range c.DefaultVersions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		version := c.DefaultVersions[id]
+		if _, err := semver.NewConstraint(version); err != nil {
+			problems = append(problems, fmt.Sprintf("default-versions.%s %q is not a valid version constraint: %s", id, version, err))
+		}
+	}
+
+	seen := map[string]int{}
+	for i, dependency := range c.Dependencies {
+		field := fmt.Sprintf("dependencies[%d]", i)
+
+		if dependency.ID == "" {
+			problems = append(problems, fmt.Sprintf("%s is missing id", field))
+		}
+
+		if dependency.Version == "" {
+			problems = append(problems, fmt.Sprintf("%s is missing version", field))
+		} else if _, err := semver.NewVersion(dependency.Version); err != nil {
+			problems = append(problems, fmt.Sprintf("%s version %q is not a valid version: %s", field, dependency.Version, err))
+		}
+
+		if dependency.URI == "" {
+			problems = append(problems, fmt.Sprintf("%s is missing uri", field))
+		}
+
+		if len(dependency.Stacks) == 0 {
+			problems = append(problems, fmt.Sprintf("%s is missing stacks", field))
+		}
+
+		problems = append(problems, validateChecksum(field, dependency)...)
+
+		for _, key := range dependencyKeys(dependency) {
+			if previous, ok := seen[key]; ok {
+				problems = append(problems, fmt.Sprintf("%s duplicates dependencies[%d] (%s %s)", field, previous, dependency.ID, dependency.Version))
+				break
+			}
+			seen[key] = i
+		}
+	}
+
+	return problems
+}
+
+func validateChecksum(field string, dependency postal.Dependency) []string {
+	if dependency.Checksum == "" && dependency.SHA256 == "" {
+		return []string{fmt.Sprintf("%s is missing checksum", field)}
+	}
+
+	var problems []string
+	if dependency.Checksum != "" {
+		algorithm, hash, found := strings.Cut(dependency.Checksum, ":")
+		if !found || algorithm == "" || !isHex(hash) {
+			problems = append(problems, fmt.Sprintf("%s checksum %q must have the form <algorithm>:<hex digest>", field, dependency.Checksum))
+		}
+	}
+
+	if dependency.SHA256 != "" && (len(dependency.SHA256) != 64 || !isHex(dependency.SHA256)) {
+		problems = append(problems, fmt.Sprintf("%s sha256 %q must be 64 hexadecimal characters", field, dependency.SHA256))
+	}
+
+	return problems
+}
+
+func isHex(value string) bool {
+	if value == "" {
+		return false
+	}
+	_, err := hex.DecodeString(value)
+	return err == nil
+}
+
+// dependencyKeys identifies a dependency by its ID and version once for each
+// of the stacks it supports, so that builds of the same version for different
+// stacks are distinct dependencies.
+func dependencyKeys(dependency postal.Dependency) []string {
+	version := dependency.Version
+	if v, err := semver.NewVersion(version); err == nil {
+		version = v.String()
+	}
+
+	var keys []string
+	for _, stack := range dependency.Stacks {
+		keys = append(keys, dependency.ID+"@"+version+"["+stack+"]")
+	}
+
+	return keys
+}
+
+// applyCatalogOverlays overlays the given catalogs on the metadata of
+// buildpack.toml. Dependencies of the catalogs replace built-in dependencies
+// with the same ID and version on the stacks they support, so a built-in
+// dependency is kept for its remaining stacks only, and all built-in
+// dependencies are discarded when any catalog is in replace mode.
+func applyCatalogOverlays(metadata BuildpackTOMLMetadata, overlays []DependencyCatalog) (BuildpackTOMLMetadata, error) {
+	definedBy := map[string]string{}
+	defaultDefinedBy := map[string]string{}
+
+	var problems []string
+	replace := false
+	defaultVersions := map[string]string{}
+	for id, version := range metadata.DefaultVersions {
+		defaultVersions[id] = version
+	}
+
+	var overlaid []postal.Dependency
+	for _, overlay := range overlays {
+		if overlay.Mode == CatalogModeReplace {
+			replace = true
+		}
+
+		for _, dependency := range overlay.Dependencies {
+			conflict := false
+			for _, key := range dependencyKeys(dependency) {
+				if source, ok := definedBy[key]; ok {
+					problems = append(problems, fmt.Sprintf("%s %s is defined by both %s and %s", dependency.ID, dependency.Version, source, overlay.Source))
+					conflict = true
+					break
+				}
+			}
+			if conflict {
+				continue
+			}
+
+			for _, key := range dependencyKeys(dependency) {
+				definedBy[key] = overlay.Source
+			}
+			overlaid = append(overlaid, dependency)
+		}
+
+		ids := make([]string, 0, len(overlay.DefaultVersions))
+		for id := range overlay.DefaultVersions {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		for _, id := range ids {
+			if source, ok := defaultDefinedBy[id]; ok {
+				problems = append(problems, fmt.Sprintf("the default version of %s is defined by both %s and %s", id, source, overlay.Source))
+				continue
+			}
+			defaultDefinedBy[id] = overlay.Source
+			defaultVersions[id] = overlay.DefaultVersions[id]
+		}
+	}
+
+	if len(problems) > 0 {
+		return BuildpackTOMLMetadata{}, fmt.Errorf("conflicting dependency catalogs:\n  %s", strings.Join(problems, "\n  "))
+	}
+
+	var dependencies []postal.Dependency
+	if !replace {
+		for _, dependency := range metadata.Dependencies {
+			var stacks []string
+			for i, key := range dependencyKeys(dependency) {
+				if _, ok := definedBy[key]; !ok {
+					stacks = append(stacks, dependency.Stacks[i])
+				}
+			}
+
+			if len(stacks) > 0 {
+				dependency.Stacks = stacks
+				dependencies = append(dependencies, dependency)
+			}
+		}
+	}
+	dependencies = append(dependencies, overlaid...)
+
+	metadata.Dependencies = dependencies
+	metadata.DefaultVersions = defaultVersions
+
+	return metadata, nil
+}
@@ -0,0 +1,356 @@
+package dotnetcoreruntime_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	dotnetcoreruntime "github.com/paketo-buildpacks/dotnet-core-runtime"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+const mirrorSHA256 = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func testDependencyCatalog(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		cnbDir        string
+		platformDir   string
+		buildpackTOML string
+	)
+
+	it.Before(func() {
+		var err error
+		cnbDir, err = os.MkdirTemp("", "cnb")
+		Expect(err).NotTo(HaveOccurred())
+
+		platformDir, err = os.MkdirTemp("", "platform")
+		Expect(err).NotTo(HaveOccurred())
+
+		buildpackTOML = filepath.Join(cnbDir, "buildpack.toml")
+		Expect(os.WriteFile(buildpackTOML, []byte(`
+[metadata]
+  [metadata.default-versions]
+    dotnet-runtime = "6.0.*"
+
+  [[metadata.dependencies]]
+    id = "dotnet-runtime"
+    sha256 = "some-sha"
+    stacks = ["some-stack"]
+    uri = "https://example.com/dotnet-runtime-6.0.13.tgz"
+    version = "6.0.13"
+
+  [[metadata.dependencies]]
+    id = "dotnet-runtime"
+    sha256 = "some-sha"
+    stacks = ["some-stack"]
+    uri = "https://example.com/dotnet-runtime-7.0.2.tgz"
+    version = "7.0.2"
+`), 0600)).To(Succeed())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(cnbDir)).To(Succeed())
+		Expect(os.RemoveAll(platformDir)).To(Succeed())
+	})
+
+	writeBinding := func(name, entry, content string) {
+		dir := filepath.Join(platformDir, "bindings", name)
+		Expect(os.MkdirAll(dir, os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "type"), []byte(dotnetcoreruntime.CatalogBindingType), 0600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, entry), []byte(content), 0600)).To(Succeed())
+	}
+
+	context("LoadDependencyCatalog", func() {
+		it("returns the dependencies of buildpack.toml when there are no overlays", func() {
+			metadata, overlays, err := dotnetcoreruntime.LoadDependencyCatalog(buildpackTOML, platformDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(overlays).To(BeEmpty())
+			Expect(metadata.Dependencies).To(HaveLen(2))
+			Expect(metadata.DefaultVersions).To(Equal(map[string]string{"dotnet-runtime": "6.0.*"}))
+		})
+
+		context("when a binding supplies a catalog in merge mode", func() {
+			it.Before(func() {
+				writeBinding("mirror", "catalog.toml", `
+[[dependencies]]
+  id = "dotnet-runtime"
+  sha256 = "`+mirrorSHA256+`"
+  stacks = ["some-stack"]
+  uri = "https://mirror.example.com/dotnet-runtime-7.0.2.tgz"
+  version = "7.0.2"
+
+[[dependencies]]
+  id = "dotnet-runtime"
+  checksum = "sha256:`+mirrorSHA256+`"
+  stacks = ["some-stack"]
+  uri = "https://mirror.example.com/dotnet-runtime-7.0.3.tgz"
+  version = "7.0.3"
+
+[[dependencies]]
+  id = "dotnet-runtime"
+  checksum = "sha256:`+mirrorSHA256+`"
+  stacks = ["other-stack"]
+  uri = "https://mirror.example.com/dotnet-runtime-6.0.13-other-stack.tgz"
+  version = "6.0.13"
+`)
+			})
+
+			it("replaces dependencies with the same version and stacks and adds the others", func() {
+				metadata, overlays, err := dotnetcoreruntime.LoadDependencyCatalog(buildpackTOML, platformDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(overlays).To(HaveLen(1))
+				Expect(overlays[0].Source).To(Equal(`binding "mirror"`))
+				Expect(overlays[0].Mode).To(Equal("merge"))
+
+				var uris []string
+				for _, dependency := range metadata.Dependencies {
+					uris = append(uris, dependency.URI)
+				}
+				Expect(uris).To(Equal([]string{
+					"https://example.com/dotnet-runtime-6.0.13.tgz",
+					"https://mirror.example.com/dotnet-runtime-7.0.2.tgz",
+					"https://mirror.example.com/dotnet-runtime-7.0.3.tgz",
+					"https://mirror.example.com/dotnet-runtime-6.0.13-other-stack.tgz",
+				}))
+				Expect(metadata.DefaultVersions).To(Equal(map[string]string{"dotnet-runtime": "6.0.*"}))
+			})
+		})
+
+		context("when a catalog overlays one stack of a built-in dependency for several stacks", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(buildpackTOML, []byte(`
+[metadata]
+  [[metadata.dependencies]]
+    id = "dotnet-runtime"
+    sha256 = "some-sha"
+    stacks = ["io.buildpacks.stacks.jammy", "io.buildpacks.stacks.bionic"]
+    uri = "https://example.com/dotnet-runtime-7.0.2.tgz"
+    version = "7.0.2"
+`), 0600)).To(Succeed())
+
+				writeBinding("mirror", "catalog.toml", `
+[[dependencies]]
+  id = "dotnet-runtime"
+  sha256 = "`+mirrorSHA256+`"
+  stacks = ["io.buildpacks.stacks.jammy"]
+  uri = "https://mirror.example.com/dotnet-runtime-7.0.2.tgz"
+  version = "7.0.2"
+`)
+			})
+
+			it("uses the overlay on its stack and keeps the built-in dependency for the others", func() {
+				metadata, _, err := dotnetcoreruntime.LoadDependencyCatalog(buildpackTOML, platformDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(metadata.Dependencies).To(Equal([]postal.Dependency{
+					{
+						ID:      "dotnet-runtime",
+						SHA256:  "some-sha",
+						Stacks:  []string{"io.buildpacks.stacks.bionic"},
+						URI:     "https://example.com/dotnet-runtime-7.0.2.tgz",
+						Version: "7.0.2",
+					},
+					{
+						ID:      "dotnet-runtime",
+						SHA256:  mirrorSHA256,
+						Stacks:  []string{"io.buildpacks.stacks.jammy"},
+						URI:     "https://mirror.example.com/dotnet-runtime-7.0.2.tgz",
+						Version: "7.0.2",
+					},
+				}))
+			})
+		})
+
+		context("when BP_DOTNET_RUNTIME_CATALOG names a JSON catalog in replace mode", func() {
+			var catalogPath string
+
+			it.Before(func() {
+				catalogPath = filepath.Join(platformDir, "catalog.json")
+				Expect(os.WriteFile(catalogPath, []byte(`{
+  "mode": "replace",
+  "default-versions": {"dotnet-runtime": "7.0.*"},
+  "dependencies": [
+    {
+      "id": "dotnet-runtime",
+      "sha256": "`+mirrorSHA256+`",
+      "stacks": ["some-stack"],
+      "uri": "https://mirror.example.com/dotnet-runtime-7.0.3.tgz",
+      "version": "7.0.3",
+      "strip-components": 1
+    }
+  ]
+}`), 0600)).To(Succeed())
+
+				Expect(os.Setenv("BP_DOTNET_RUNTIME_CATALOG", catalogPath)).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_DOTNET_RUNTIME_CATALOG")).To(Succeed())
+			})
+
+			it("discards the built-in dependencies", func() {
+				metadata, overlays, err := dotnetcoreruntime.LoadDependencyCatalog(buildpackTOML, platformDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(overlays).To(HaveLen(1))
+				Expect(overlays[0].Source).To(Equal(catalogPath))
+				Expect(metadata.Dependencies).To(Equal([]postal.Dependency{
+					{
+						ID:              "dotnet-runtime",
+						SHA256:          mirrorSHA256,
+						Stacks:          []string{"some-stack"},
+						URI:             "https://mirror.example.com/dotnet-runtime-7.0.3.tgz",
+						Version:         "7.0.3",
+						StripComponents: 1,
+					},
+				}))
+				Expect(metadata.DefaultVersions).To(Equal(map[string]string{"dotnet-runtime": "7.0.*"}))
+			})
+
+			context("when a binding defines the same dependency", func() {
+				it.Before(func() {
+					writeBinding("mirror", "catalog.toml", `
+[[dependencies]]
+  id = "dotnet-runtime"
+  sha256 = "`+mirrorSHA256+`"
+  stacks = ["some-stack"]
+  uri = "https://other.example.com/dotnet-runtime-7.0.3.tgz"
+  version = "7.0.3"
+`)
+				})
+
+				it("returns an error", func() {
+					_, _, err := dotnetcoreruntime.LoadDependencyCatalog(buildpackTOML, platformDir)
+					Expect(err).To(MatchError(ContainSubstring(`dotnet-runtime 7.0.3 is defined by both binding "mirror" and ` + catalogPath)))
+				})
+			})
+		})
+
+		context("failure cases", func() {
+			context("when a binding has no catalog entry", func() {
+				it.Before(func() {
+					writeBinding("mirror", "other", "")
+				})
+
+				it("returns an error", func() {
+					_, _, err := dotnetcoreruntime.LoadDependencyCatalog(buildpackTOML, platformDir)
+					Expect(err).To(MatchError(`binding "mirror" of type dotnet-runtime-catalog must contain exactly one of catalog.toml or catalog.json`))
+				})
+			})
+
+			context("when BP_DOTNET_RUNTIME_CATALOG does not exist", func() {
+				it.Before(func() {
+					Expect(os.Setenv("BP_DOTNET_RUNTIME_CATALOG", filepath.Join(platformDir, "missing.toml"))).To(Succeed())
+				})
+
+				it.After(func() {
+					Expect(os.Unsetenv("BP_DOTNET_RUNTIME_CATALOG")).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, _, err := dotnetcoreruntime.LoadDependencyCatalog(buildpackTOML, platformDir)
+					Expect(err).To(MatchError(ContainSubstring("failed to read BP_DOTNET_RUNTIME_CATALOG")))
+					Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+				})
+			})
+		})
+	})
+
+	context("ParseDependencyCatalog", func() {
+		it("reports every problem with the catalog", func() {
+			path := filepath.Join(platformDir, "catalog.toml")
+			Expect(os.WriteFile(path, []byte(`
+mode = "overwrite"
+
+[default-versions]
+  dotnet-runtime = "not a version"
+
+[[dependencies]]
+  id = "dotnet-runtime"
+  sha256 = "not-hex"
+  version = "7.0.3"
+
+[[dependencies]]
+  id = "dotnet-runtime"
+  checksum = "`+mirrorSHA256+`"
+  stacks = ["some-stack"]
+  uri = "https://mirror.example.com/dotnet-runtime-7.0.3.tgz"
+  version = "7.0.3"
+
+[[dependencies]]
+  stacks = ["some-stack"]
+  uri = "https://mirror.example.com/dotnet-runtime.tgz"
+  version = "latest"
+
+[[dependencies]]
+  id = "dotnet-runtime"
+  checksum = "sha256:`+mirrorSHA256+`"
+  stacks = ["other-stack", "some-stack"]
+  uri = "https://mirror.example.com/dotnet-runtime-7.0.3.tgz"
+  version = "7.0.3"
+`), 0600)).To(Succeed())
+
+			_, err := dotnetcoreruntime.ParseDependencyCatalog(path)
+			Expect(err).To(MatchError(ContainSubstring("invalid dependency catalog " + path + ":\n")))
+			Expect(err).To(MatchError(ContainSubstring(`mode "overwrite" must be "merge" or "replace"`)))
+			Expect(err).To(MatchError(ContainSubstring(`default-versions.dotnet-runtime "not a version" is not a valid version constraint`)))
+			Expect(err).To(MatchError(ContainSubstring("dependencies[0] is missing uri")))
+			Expect(err).To(MatchError(ContainSubstring("dependencies[0] is missing stacks")))
+			Expect(err).To(MatchError(ContainSubstring(`dependencies[0] sha256 "not-hex" must be 64 hexadecimal characters`)))
+			Expect(err).To(MatchError(ContainSubstring(`dependencies[1] checksum "` + mirrorSHA256 + `" must have the form <algorithm>:<hex digest>`)))
+			Expect(err).To(MatchError(ContainSubstring("dependencies[2] is missing id")))
+			Expect(err).To(MatchError(ContainSubstring(`dependencies[2] version "latest" is not a valid version`)))
+			Expect(err).To(MatchError(ContainSubstring("dependencies[2] is missing checksum")))
+			Expect(err).To(MatchError(ContainSubstring("dependencies[3] duplicates dependencies[1] (dotnet-runtime 7.0.3)")))
+		})
+
+		it("accepts the same version for different stacks", func() {
+			path := filepath.Join(platformDir, "catalog.toml")
+			Expect(os.WriteFile(path, []byte(`
+[[dependencies]]
+  id = "dotnet-runtime"
+  checksum = "sha256:`+mirrorSHA256+`"
+  stacks = ["some-stack", "other-stack"]
+  uri = "https://mirror.example.com/dotnet-runtime-7.0.3.tgz"
+  version = "7.0.3"
+
+[[dependencies]]
+  id = "dotnet-runtime"
+  checksum = "sha256:`+mirrorSHA256+`"
+  stacks = ["another-stack"]
+  uri = "https://mirror.example.com/dotnet-runtime-7.0.3-another-stack.tgz"
+  version = "7.0.3"
+`), 0600)).To(Succeed())
+
+			catalog, err := dotnetcoreruntime.ParseDependencyCatalog(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(catalog.Dependencies).To(HaveLen(2))
+		})
+
+		context("when the file is neither TOML nor JSON", func() {
+			it("returns an error", func() {
+				path := filepath.Join(platformDir, "catalog.yml")
+				Expect(os.WriteFile(path, []byte(""), 0600)).To(Succeed())
+
+				_, err := dotnetcoreruntime.ParseDependencyCatalog(path)
+				Expect(err).To(MatchError(ContainSubstring("expected a .toml or .json file")))
+			})
+		})
+
+		context("when the file is malformed", func() {
+			it("returns an error", func() {
+				path := filepath.Join(platformDir, "catalog.json")
+				Expect(os.WriteFile(path, []byte("{"), 0600)).To(Succeed())
+
+				_, err := dotnetcoreruntime.ParseDependencyCatalog(path)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse dependency catalog")))
+			})
+		})
+	})
+}
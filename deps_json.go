@@ -0,0 +1,146 @@
+package dotnetcoreruntime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+type DepsJSONParser struct{}
+
+func NewDepsJSONParser() DepsJSONParser {
+	return DepsJSONParser{}
+}
+
+// ParseVersion returns the runtime version that the application described by
+// the .deps.json file at the given path was compiled against: the version of
+// its Microsoft.NETCore.App reference when it has one, otherwise the lowest
+// version of the framework named by runtimeTarget. It returns an empty string
+// when the file does not exist or does not target .NET Core.
+func (p DepsJSONParser) ParseVersion(path string) (string, error) {
+	deps, err := readDepsJSON(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return deps.runtimeVersion(), nil
+}
+
+type depsJSON struct {
+	RuntimeTarget struct {
+		Name string `json:"name"`
+	} `json:"runtimeTarget"`
+	Targets map[string]map[string]json.RawMessage `json:"targets"`
+}
+
+func readDepsJSON(path string) (depsJSON, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return depsJSON{}, err
+	}
+
+	var deps depsJSON
+	err = json.Unmarshal(content, &deps)
+	if err != nil {
+		return depsJSON{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return deps, nil
+}
+
+var runtimeTargetName = regexp.MustCompile(`^\.NETCoreApp,Version=v(\d+\.\d+)$`)
+
+func (d depsJSON) runtimeVersion() string {
+	matches := runtimeTargetName.FindStringSubmatch(d.RuntimeTarget.Name)
+	if matches == nil {
+		return ""
+	}
+
+	for library := range d.Targets[d.RuntimeTarget.Name] {
+		if version := strings.TrimPrefix(library, "Microsoft.NETCore.App/"); version != library {
+			return version
+		}
+	}
+
+	return matches[1] + ".0"
+}
+
+// DepsJSONMismatch describes an application whose .deps.json and
+// *.runtimeconfig.json files target different runtime versions.
+type DepsJSONMismatch struct {
+	RuntimeConfigPath    string
+	RuntimeConfigVersion string
+	DepsJSONPath         string
+	DepsJSONVersion      string
+}
+
+func (m DepsJSONMismatch) String() string {
+	return fmt.Sprintf("%s targets Microsoft.NETCore.App %s, but %s targets %s",
+		filepath.Base(m.RuntimeConfigPath), m.RuntimeConfigVersion, filepath.Base(m.DepsJSONPath), m.DepsJSONVersion)
+}
+
+// CrossCheckDepsJSON compares the Microsoft.NETCore.App version of every
+// *.runtimeconfig.json file at the root of the project directory with the
+// runtime targeted by the .deps.json file of the same application, and returns
+// those whose major and minor versions differ.
+func CrossCheckDepsJSON(projectDir string) ([]DepsJSONMismatch, error) {
+	paths, err := filepath.Glob(filepath.Join(projectDir, "*.runtimeconfig.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var mismatches []DepsJSONMismatch
+	for _, path := range paths {
+		depsPath := strings.TrimSuffix(path, ".runtimeconfig.json") + ".deps.json"
+		deps, err := readDepsJSON(depsPath)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		depsVersion, err := semver.NewVersion(deps.runtimeVersion())
+		if err != nil {
+			continue
+		}
+
+		config, err := readRuntimeConfig(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, framework := range config.frameworks() {
+			if framework.Name != "Microsoft.NETCore.App" {
+				continue
+			}
+
+			version, err := semver.NewVersion(framework.Version)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse framework version %q in %s: %w", framework.Version, path, err)
+			}
+
+			if version.Major() != depsVersion.Major() || version.Minor() != depsVersion.Minor() {
+				mismatches = append(mismatches, DepsJSONMismatch{
+					RuntimeConfigPath:    path,
+					RuntimeConfigVersion: framework.Version,
+					DepsJSONPath:         depsPath,
+					DepsJSONVersion:      depsVersion.Original(),
+				})
+			}
+		}
+	}
+
+	return mismatches, nil
+}
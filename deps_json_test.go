@@ -0,0 +1,164 @@
+package dotnetcoreruntime_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	dotnetcoreruntime "github.com/paketo-buildpacks/dotnet-core-runtime"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testDepsJSON(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		workingDir string
+	)
+
+	write := func(name, content string) {
+		Expect(os.WriteFile(filepath.Join(workingDir, name), []byte(content), 0600)).To(Succeed())
+	}
+
+	it.Before(func() {
+		var err error
+		workingDir, err = os.MkdirTemp("", "working-dir")
+		Expect(err).NotTo(HaveOccurred())
+
+		write("app.deps.json", `{
+  "runtimeTarget": {"name": ".NETCoreApp,Version=v6.0", "signature": ""},
+  "targets": {
+    ".NETCoreApp,Version=v6.0": {
+      "app/1.0.0": {"dependencies": {"Newtonsoft.Json": "13.0.1"}},
+      "Newtonsoft.Json/13.0.1": {}
+    }
+  }
+}`)
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	context("DepsJSONParser", func() {
+		var parser dotnetcoreruntime.DepsJSONParser
+
+		it.Before(func() {
+			parser = dotnetcoreruntime.NewDepsJSONParser()
+		})
+
+		it("returns the lowest version of the runtime target", func() {
+			version, err := parser.ParseVersion(filepath.Join(workingDir, "app.deps.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal("6.0.0"))
+		})
+
+		context("when the application references Microsoft.NETCore.App", func() {
+			it.Before(func() {
+				write("app.deps.json", `{
+  "runtimeTarget": {"name": ".NETCoreApp,Version=v2.1"},
+  "targets": {
+    ".NETCoreApp,Version=v2.1": {
+      "app/1.0.0": {"dependencies": {"Microsoft.NETCore.App": "2.1.30"}},
+      "Microsoft.NETCore.App/2.1.30": {}
+    }
+  }
+}`)
+			})
+
+			it("returns the referenced version", func() {
+				version, err := parser.ParseVersion(filepath.Join(workingDir, "app.deps.json"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(Equal("2.1.30"))
+			})
+		})
+
+		context("when the runtime target is not .NET Core", func() {
+			it.Before(func() {
+				write("app.deps.json", `{"runtimeTarget": {"name": ".NETStandard,Version=v2.0/"}}`)
+			})
+
+			it("returns an empty version", func() {
+				version, err := parser.ParseVersion(filepath.Join(workingDir, "app.deps.json"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(BeEmpty())
+			})
+		})
+
+		context("when the file does not exist", func() {
+			it("returns an empty version", func() {
+				version, err := parser.ParseVersion(filepath.Join(workingDir, "other.deps.json"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(BeEmpty())
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the file is malformed", func() {
+				it.Before(func() {
+					write("app.deps.json", "%%%")
+				})
+
+				it("returns an error", func() {
+					_, err := parser.ParseVersion(filepath.Join(workingDir, "app.deps.json"))
+					Expect(err).To(MatchError(ContainSubstring("failed to parse " + filepath.Join(workingDir, "app.deps.json"))))
+				})
+			})
+		})
+	})
+
+	context("CrossCheckDepsJSON", func() {
+		context("when the runtimeconfig.json agrees with the .deps.json", func() {
+			it.Before(func() {
+				write("app.runtimeconfig.json", `{"runtimeOptions": {"framework": {"name": "Microsoft.NETCore.App", "version": "6.0.0"}}}`)
+			})
+
+			it("returns no mismatches", func() {
+				mismatches, err := dotnetcoreruntime.CrossCheckDepsJSON(workingDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mismatches).To(BeEmpty())
+			})
+		})
+
+		context("when the runtimeconfig.json disagrees with the .deps.json", func() {
+			it.Before(func() {
+				write("app.runtimeconfig.json", `{"runtimeOptions": {"framework": {"name": "Microsoft.NETCore.App", "version": "7.0.0"}}}`)
+			})
+
+			it("returns the mismatch", func() {
+				mismatches, err := dotnetcoreruntime.CrossCheckDepsJSON(workingDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mismatches).To(HaveLen(1))
+				Expect(mismatches[0].String()).To(Equal("app.runtimeconfig.json targets Microsoft.NETCore.App 7.0.0, but app.deps.json targets 6.0.0"))
+			})
+		})
+
+		context("when the application has no .deps.json", func() {
+			it.Before(func() {
+				Expect(os.Remove(filepath.Join(workingDir, "app.deps.json"))).To(Succeed())
+				write("app.runtimeconfig.json", `{"runtimeOptions": {"framework": {"name": "Microsoft.NETCore.App", "version": "7.0.0"}}}`)
+			})
+
+			it("returns no mismatches", func() {
+				mismatches, err := dotnetcoreruntime.CrossCheckDepsJSON(workingDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mismatches).To(BeEmpty())
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the framework version is invalid", func() {
+				it.Before(func() {
+					write("app.runtimeconfig.json", `{"runtimeOptions": {"framework": {"name": "Microsoft.NETCore.App", "version": "latest"}}}`)
+				})
+
+				it("returns an error", func() {
+					_, err := dotnetcoreruntime.CrossCheckDepsJSON(workingDir)
+					Expect(err).To(MatchError(ContainSubstring(`failed to parse framework version "latest"`)))
+				})
+			})
+		})
+	})
+}
@@ -1,10 +1,18 @@
 package dotnetcoreruntime
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/Masterminds/semver"
 	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
 )
 
 //go:generate faux --interface VersionParser --output fakes/version_parser.go
@@ -12,24 +20,64 @@ type VersionParser interface {
 	ParseVersion(path string) (version string, err error)
 }
 
-func Detect(buildpackYMLParser VersionParser) packit.DetectFunc {
+//go:generate faux --interface SDKParser --output fakes/sdk_parser.go
+type SDKParser interface {
+	ParseSDK(path string) (version, rollForward string, err error)
+}
+
+//go:generate faux --interface ProjectParser --output fakes/project_parser.go
+type ProjectParser interface {
+	ParseVersion(projectDir, workingDir string) (version, source string, err error)
+}
+
+func Detect(buildpackYMLParser, versionFileParser VersionParser, toolVersionsParser, globalJSONParser SDKParser, depsJSONParser VersionParser, projectParser ProjectParser, logger scribe.Emitter) packit.DetectFunc {
 	return func(context packit.DetectContext) (packit.DetectResult, error) {
 		var requirements []packit.BuildPlanRequirement
 
+		projectDir, err := projectDirectory(context.WorkingDir)
+		if err != nil {
+			return packit.DetectResult{}, err
+		}
+
 		// check if BP_DOTNET_FRAMEWORK_VERSION is set
-		if version, ok := os.LookupEnv("BP_DOTNET_FRAMEWORK_VERSION"); ok {
+		if original, ok := os.LookupEnv("BP_DOTNET_FRAMEWORK_VERSION"); ok {
+			version, err := normalizeFrameworkVersion(original)
+			if err != nil {
+				return packit.DetectResult{}, err
+			}
+
+			metadata := map[string]interface{}{
+				"version-source": "BP_DOTNET_FRAMEWORK_VERSION",
+				"version":        version,
+			}
+			if version != original {
+				metadata["original-version"] = original
+			}
+
+			requirements = append(requirements, packit.BuildPlanRequirement{
+				Name:     "dotnet-runtime",
+				Metadata: metadata,
+			})
+		}
+
+		// check if the version is set in a .dotnet-version file
+		version, err := versionFileParser.ParseVersion(nearestFile(".dotnet-version", projectDir, context.WorkingDir))
+		if err != nil {
+			return packit.DetectResult{}, err
+		}
+
+		if version != "" {
 			requirements = append(requirements, packit.BuildPlanRequirement{
 				Name: "dotnet-runtime",
 				Metadata: map[string]interface{}{
-					"version-source": "BP_DOTNET_FRAMEWORK_VERSION",
+					"version-source": ".dotnet-version",
 					"version":        version,
 				},
 			})
-
 		}
 
 		// check if the version is set in the buildpack.yml
-		version, err := buildpackYMLParser.ParseVersion(filepath.Join(context.WorkingDir, "buildpack.yml"))
+		version, err = buildpackYMLParser.ParseVersion(nearestFile("buildpack.yml", projectDir, context.WorkingDir))
 		if err != nil {
 			return packit.DetectResult{}, err
 		}
@@ -44,6 +92,115 @@ func Detect(buildpackYMLParser VersionParser) packit.DetectFunc {
 			})
 		}
 
+		// check if the version is set in the project file or the files it inherits from
+		version, source, err := projectParser.ParseVersion(projectDir, context.WorkingDir)
+		if err != nil {
+			return packit.DetectResult{}, err
+		}
+
+		if version != "" {
+			requirements = append(requirements, packit.BuildPlanRequirement{
+				Name: "dotnet-runtime",
+				Metadata: map[string]interface{}{
+					"version-source": source,
+					"version":        version,
+				},
+			})
+		}
+
+		// check the .deps.json of a framework-dependent publish output that has no runtimeconfig.json
+		depsJSONPath, err := fallbackDepsJSON(projectDir)
+		if err != nil {
+			return packit.DetectResult{}, err
+		}
+
+		if depsJSONPath != "" {
+			version, err := depsJSONParser.ParseVersion(depsJSONPath)
+			if err != nil {
+				return packit.DetectResult{}, err
+			}
+
+			if version != "" {
+				requirements = append(requirements, packit.BuildPlanRequirement{
+					Name: "dotnet-runtime",
+					Metadata: map[string]interface{}{
+						"version-source": filepath.Base(depsJSONPath),
+						"version":        version,
+					},
+				})
+			}
+		}
+
+		// check if an SDK is pinned in a .tool-versions file or the global.json
+		var mappings []SDKRuntimeMapping
+		for _, pin := range []struct {
+			name   string
+			parser SDKParser
+		}{
+			{name: ".tool-versions", parser: toolVersionsParser},
+			{name: "global.json", parser: globalJSONParser},
+		} {
+			path := nearestFile(pin.name, projectDir, context.WorkingDir)
+			sdkVersion, sdkRollForward, err := pin.parser.ParseSDK(path)
+			if err != nil {
+				return packit.DetectResult{}, err
+			}
+
+			if sdkVersion == "" {
+				continue
+			}
+
+			if mappings == nil {
+				buildpackTOML, err := ParseBuildpackTOML(filepath.Join(context.CNBPath, "buildpack.toml"))
+				if err != nil {
+					return packit.DetectResult{}, err
+				}
+				mappings = buildpackTOML.Metadata.SDKRuntimeMappings
+			}
+
+			version, err := RuntimeVersionForSDK(sdkVersion, sdkRollForward, mappings)
+			if err != nil {
+				return packit.DetectResult{}, fmt.Errorf("failed to map %s SDK version to a runtime version: %w", pin.name, err)
+			}
+
+			if version == "" {
+				logger.Process("No runtime version is known for SDK %s, which %s pins; ignoring it", sdkVersion, path)
+				continue
+			}
+
+			requirements = append(requirements, packit.BuildPlanRequirement{
+				Name: "dotnet-runtime",
+				Metadata: map[string]interface{}{
+					"version-source": pin.name,
+					"version":        version,
+					"sdk-version":    sdkVersion,
+				},
+			})
+		}
+
+		// apply BP_DOTNET_RUNTIME_LAUNCH and BP_DOTNET_RUNTIME_BUILD so that the
+		// runtime is available without a downstream buildpack requiring it
+		layerTypes, err := configuredLayerTypes()
+		if err != nil {
+			return packit.DetectResult{}, err
+		}
+
+		if len(layerTypes) > 0 {
+			if len(requirements) == 0 {
+				requirements = append(requirements, packit.BuildPlanRequirement{
+					Name:     "dotnet-runtime",
+					Metadata: map[string]interface{}{},
+				})
+			}
+
+			for _, requirement := range requirements {
+				metadata := requirement.Metadata.(map[string]interface{})
+				for layerType, enabled := range layerTypes {
+					metadata[layerType] = enabled
+				}
+			}
+		}
+
 		return packit.DetectResult{
 			Plan: packit.BuildPlan{
 				Provides: []packit.BuildPlanProvision{
@@ -54,3 +211,138 @@ func Detect(buildpackYMLParser VersionParser) packit.DetectFunc {
 		}, nil
 	}
 }
+
+// configuredLayerTypes returns the layer types set by BP_DOTNET_RUNTIME_LAUNCH
+// and BP_DOTNET_RUNTIME_BUILD, keyed by the build plan metadata field they
+// set.
+func configuredLayerTypes() (map[string]bool, error) {
+	layerTypes := map[string]bool{}
+	for layerType, name := range map[string]string{
+		"launch": "BP_DOTNET_RUNTIME_LAUNCH",
+		"build":  "BP_DOTNET_RUNTIME_BUILD",
+	} {
+		value, ok := os.LookupEnv(name)
+		if !ok || value == "" {
+			continue
+		}
+
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s value %q: %w", name, value, err)
+		}
+		layerTypes[layerType] = enabled
+	}
+
+	return layerTypes, nil
+}
+
+// projectDirectory returns the directory selected by BP_DOTNET_PROJECT_PATH,
+// in which the version sources of the application are looked up, or the
+// working directory when it is unset.
+func projectDirectory(workingDir string) (string, error) {
+	value := os.Getenv("BP_DOTNET_PROJECT_PATH")
+	if value == "" {
+		return workingDir, nil
+	}
+
+	projectDir := value
+	if !filepath.IsAbs(projectDir) {
+		projectDir = filepath.Join(workingDir, projectDir)
+	}
+	projectDir = filepath.Clean(projectDir)
+
+	rel, err := filepath.Rel(workingDir, projectDir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid BP_DOTNET_PROJECT_PATH value %q: must be a directory inside the application directory %s", value, workingDir)
+	}
+
+	info, err := os.Stat(projectDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("invalid BP_DOTNET_PROJECT_PATH value %q: %s does not exist", value, projectDir)
+		}
+		return "", err
+	}
+
+	if !info.IsDir() {
+		return "", fmt.Errorf("invalid BP_DOTNET_PROJECT_PATH value %q: %s is not a directory", value, projectDir)
+	}
+
+	return projectDir, nil
+}
+
+// fallbackDepsJSON returns the first *.deps.json file in the project
+// directory, when the directory has no *.runtimeconfig.json file from which
+// the runtime version would otherwise be read.
+func fallbackDepsJSON(projectDir string) (string, error) {
+	runtimeConfigs, err := filepath.Glob(filepath.Join(projectDir, "*.runtimeconfig.json"))
+	if err != nil {
+		return "", err
+	}
+
+	if len(runtimeConfigs) > 0 {
+		return "", nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(projectDir, "*.deps.json"))
+	if err != nil {
+		return "", err
+	}
+
+	if len(paths) == 0 {
+		return "", nil
+	}
+	sort.Strings(paths)
+
+	return paths[0], nil
+}
+
+// nearestFile returns the path of the file with the given name that tools such
+// as the dotnet CLI would use for the project directory: the first one found
+// in the project directory or its parents, up to the working directory.
+func nearestFile(name, projectDir, workingDir string) string {
+	for dir := projectDir; ; dir = filepath.Dir(dir) {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil || dir == workingDir || dir == filepath.Dir(dir) {
+			return path
+		}
+	}
+}
+
+var (
+	targetFrameworkVersion = regexp.MustCompile(`^(?i:netcoreapp|net|v)(\d.*)$`)
+	partialVersion         = regexp.MustCompile(`^\d+(\.\d+)?$`)
+)
+
+// normalizeFrameworkVersion turns the common ways of writing a framework
+// version, such as net6.0, v6.0.1, 6.0 or 6.0.x, into a version constraint
+// that the resolver understands, and rejects values that are not valid
+// constraints.
+func normalizeFrameworkVersion(value string) (string, error) {
+	version := strings.TrimSpace(value)
+	if version == "" || version == "default" {
+		return version, nil
+	}
+
+	if matches := targetFrameworkVersion.FindStringSubmatch(version); matches != nil {
+		version = matches[1]
+	}
+
+	segments := strings.Split(version, ".")
+	for i, segment := range segments {
+		if segment == "x" || segment == "X" {
+			segments[i] = "*"
+		}
+	}
+	version = strings.Join(segments, ".")
+
+	if partialVersion.MatchString(version) {
+		version = version + ".*"
+	}
+
+	if _, err := semver.NewConstraint(version); err != nil {
+		return "", fmt.Errorf("invalid BP_DOTNET_FRAMEWORK_VERSION value %q: expected a version such as 6.0.1 or a constraint such as 6.0.*: %w", value, err)
+	}
+
+	return version, nil
+}
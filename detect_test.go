@@ -1,13 +1,17 @@
 package dotnetcoreruntime_test
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 
 	dotnetcoreruntime "github.com/paketo-buildpacks/dotnet-core-runtime"
 	"github.com/paketo-buildpacks/dotnet-core-runtime/fakes"
 	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
 	"github.com/sclevine/spec"
 
 	. "github.com/onsi/gomega"
@@ -18,7 +22,14 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 		Expect = NewWithT(t).Expect
 
 		buildpackYMLParser *fakes.VersionParser
+		versionFileParser  *fakes.VersionParser
+		toolVersionsParser *fakes.SDKParser
+		globalJSONParser   *fakes.SDKParser
+		depsJSONParser     *fakes.VersionParser
+		projectParser      *fakes.ProjectParser
 		workingDir         string
+		cnbDir             string
+		buffer             *bytes.Buffer
 		detect             packit.DetectFunc
 	)
 
@@ -27,12 +38,29 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 		workingDir, err = os.MkdirTemp("", "working-dir")
 		Expect(err).NotTo(HaveOccurred())
 
+		cnbDir, err = os.MkdirTemp("", "cnb")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.WriteFile(filepath.Join(cnbDir, "buildpack.toml"), []byte(`api = "0.8"
+[metadata]
+  [[metadata.sdk-runtime-mappings]]
+    runtime = "6.0.*"
+    sdk = "6.0.4xx"
+`), 0600)).To(Succeed())
+
 		buildpackYMLParser = &fakes.VersionParser{}
-		detect = dotnetcoreruntime.Detect(buildpackYMLParser)
+		versionFileParser = &fakes.VersionParser{}
+		toolVersionsParser = &fakes.SDKParser{}
+		globalJSONParser = &fakes.SDKParser{}
+		depsJSONParser = &fakes.VersionParser{}
+		projectParser = &fakes.ProjectParser{}
+		buffer = bytes.NewBuffer(nil)
+		detect = dotnetcoreruntime.Detect(buildpackYMLParser, versionFileParser, toolVersionsParser, globalJSONParser, depsJSONParser, projectParser, scribe.NewEmitter(buffer))
 	})
 
 	it.After(func() {
 		Expect(os.RemoveAll(workingDir)).To(Succeed())
+		Expect(os.RemoveAll(cnbDir)).To(Succeed())
 	})
 
 	context("when there is no buildpack.yml and BP_DOTNET_FRAMEWORK_VERSION is unset", func() {
@@ -84,6 +112,43 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 
+	context("when BP_DOTNET_FRAMEWORK_VERSION needs to be normalised", func() {
+		it.After(func() {
+			Expect(os.Unsetenv("BP_DOTNET_FRAMEWORK_VERSION")).To(Succeed())
+		})
+
+		for original, normalised := range map[string]string{
+			"net6.0":        "6.0.*",
+			"netcoreapp3.1": "3.1.*",
+			"6.0":           "6.0.*",
+			"6":             "6.*",
+			"v6.0.1":        "6.0.1",
+			"6.0.x":         "6.0.*",
+			" 6.0.1 ":       "6.0.1",
+		} {
+			original, normalised := original, normalised
+
+			it(fmt.Sprintf("requires %s and records %q as the original version", normalised, original), func() {
+				Expect(os.Setenv("BP_DOTNET_FRAMEWORK_VERSION", original)).To(Succeed())
+
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Plan.Requires).To(Equal([]packit.BuildPlanRequirement{
+					{
+						Name: "dotnet-runtime",
+						Metadata: map[string]interface{}{
+							"version-source":   "BP_DOTNET_FRAMEWORK_VERSION",
+							"version":          normalised,
+							"original-version": original,
+						},
+					},
+				}))
+			})
+		}
+	})
+
 	context("when there is a buildpack.yml", func() {
 		it.Before(func() {
 			buildpackYMLParser.ParseVersionCall.Returns.Version = "1.2.3"
@@ -113,7 +178,510 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 
+	context("when there is a .dotnet-version file", func() {
+		it.Before(func() {
+			versionFileParser.ParseVersionCall.Returns.Version = "6.0.13"
+		})
+
+		it("requires its version", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan.Requires).To(Equal([]packit.BuildPlanRequirement{
+				{
+					Name: "dotnet-runtime",
+					Metadata: map[string]interface{}{
+						"version-source": ".dotnet-version",
+						"version":        "6.0.13",
+					},
+				},
+			}))
+
+			Expect(versionFileParser.ParseVersionCall.Receives.Path).To(Equal(filepath.Join(workingDir, ".dotnet-version")))
+		})
+	})
+
+	context("when an SDK is pinned in a .tool-versions file", func() {
+		it.Before(func() {
+			toolVersionsParser.ParseSDKCall.Returns.Version = "6.0.404"
+		})
+
+		it("requires the runtime line of that SDK", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan.Requires).To(Equal([]packit.BuildPlanRequirement{
+				{
+					Name: "dotnet-runtime",
+					Metadata: map[string]interface{}{
+						"version-source": ".tool-versions",
+						"version":        "6.0.*",
+						"sdk-version":    "6.0.404",
+					},
+				},
+			}))
+
+			Expect(toolVersionsParser.ParseSDKCall.Receives.Path).To(Equal(filepath.Join(workingDir, ".tool-versions")))
+		})
+
+		context("when no runtime version is known for the SDK", func() {
+			it.Before(func() {
+				toolVersionsParser.ParseSDKCall.Returns.Version = "8.0.100"
+			})
+
+			it("ignores it", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Plan.Requires).To(BeEmpty())
+				Expect(buffer.String()).To(ContainSubstring(fmt.Sprintf("No runtime version is known for SDK 8.0.100, which %s pins; ignoring it", filepath.Join(workingDir, ".tool-versions"))))
+			})
+		})
+	})
+
+	context("when the version is set in a project file or a file it inherits from", func() {
+		it.Before(func() {
+			projectParser.ParseVersionCall.Returns.Version = "6.0.0"
+			projectParser.ParseVersionCall.Returns.Source = "Directory.Build.props"
+		})
+
+		it("requires the version with the defining file as the version source", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan.Requires).To(Equal([]packit.BuildPlanRequirement{
+				{
+					Name: "dotnet-runtime",
+					Metadata: map[string]interface{}{
+						"version-source": "Directory.Build.props",
+						"version":        "6.0.0",
+					},
+				},
+			}))
+
+			Expect(projectParser.ParseVersionCall.Receives.ProjectDir).To(Equal(workingDir))
+			Expect(projectParser.ParseVersionCall.Receives.WorkingDir).To(Equal(workingDir))
+		})
+	})
+
+	context("when there is a .deps.json", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "app.deps.json"), []byte(`{}`), 0600)).To(Succeed())
+			depsJSONParser.ParseVersionCall.Returns.Version = "6.0.0"
+		})
+
+		it("requires the version that the application was compiled against", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan.Requires).To(Equal([]packit.BuildPlanRequirement{
+				{
+					Name: "dotnet-runtime",
+					Metadata: map[string]interface{}{
+						"version-source": "app.deps.json",
+						"version":        "6.0.0",
+					},
+				},
+			}))
+
+			Expect(depsJSONParser.ParseVersionCall.Receives.Path).To(Equal(filepath.Join(workingDir, "app.deps.json")))
+		})
+
+		context("when there is also a runtimeconfig.json", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "app.runtimeconfig.json"), []byte(`{}`), 0600)).To(Succeed())
+			})
+
+			it("does not read the .deps.json", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Plan.Requires).To(BeEmpty())
+				Expect(depsJSONParser.ParseVersionCall.CallCount).To(Equal(0))
+			})
+		})
+	})
+
+	context("when an SDK is pinned in global.json", func() {
+		it.Before(func() {
+			globalJSONParser.ParseSDKCall.Returns.Version = "6.0.402"
+		})
+
+		it("requires the runtime line that the SDK ships with", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan.Requires).To(Equal([]packit.BuildPlanRequirement{
+				{
+					Name: "dotnet-runtime",
+					Metadata: map[string]interface{}{
+						"version-source": "global.json",
+						"version":        "6.0.*",
+						"sdk-version":    "6.0.402",
+					},
+				},
+			}))
+
+			Expect(globalJSONParser.ParseSDKCall.Receives.Path).To(Equal(filepath.Join(workingDir, "global.json")))
+		})
+
+		context("when no mapping matches the SDK", func() {
+			it.Before(func() {
+				globalJSONParser.ParseSDKCall.Returns.Version = "8.0.100"
+			})
+
+			it("does not require a runtime version and says why", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Plan.Requires).To(BeEmpty())
+				Expect(buffer.String()).To(ContainSubstring(fmt.Sprintf("No runtime version is known for SDK 8.0.100, which %s pins; ignoring it", filepath.Join(workingDir, "global.json"))))
+			})
+		})
+
+		context("when the SDK may roll forward to a newer minor version", func() {
+			it.Before(func() {
+				globalJSONParser.ParseSDKCall.Returns.RollForward = "latestMinor"
+			})
+
+			it("requires any runtime of the same major version", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Plan.Requires).To(Equal([]packit.BuildPlanRequirement{
+					{
+						Name: "dotnet-runtime",
+						Metadata: map[string]interface{}{
+							"version-source": "global.json",
+							"version":        "6.*",
+							"sdk-version":    "6.0.402",
+						},
+					},
+				}))
+			})
+		})
+	})
+
+	context("when BP_DOTNET_RUNTIME_LAUNCH and BP_DOTNET_RUNTIME_BUILD are set", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_DOTNET_RUNTIME_LAUNCH", "true")).To(Succeed())
+			Expect(os.Setenv("BP_DOTNET_RUNTIME_BUILD", "false")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_DOTNET_RUNTIME_LAUNCH")).To(Succeed())
+			Expect(os.Unsetenv("BP_DOTNET_RUNTIME_BUILD")).To(Succeed())
+		})
+
+		it("requires the runtime with those layer types", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan.Requires).To(Equal([]packit.BuildPlanRequirement{
+				{
+					Name: "dotnet-runtime",
+					Metadata: map[string]interface{}{
+						"launch": true,
+						"build":  false,
+					},
+				},
+			}))
+		})
+
+		context("when a version is required", func() {
+			it.Before(func() {
+				buildpackYMLParser.ParseVersionCall.Returns.Version = "1.2.3"
+			})
+
+			it("adds the layer types to the requirement", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Plan.Requires).To(Equal([]packit.BuildPlanRequirement{
+					{
+						Name: "dotnet-runtime",
+						Metadata: map[string]interface{}{
+							"version-source": "buildpack.yml",
+							"version":        "1.2.3",
+							"launch":         true,
+							"build":          false,
+						},
+					},
+				}))
+			})
+		})
+	})
+
+	context("when BP_DOTNET_PROJECT_PATH is set", func() {
+		var projectDir string
+
+		it.Before(func() {
+			projectDir = filepath.Join(workingDir, "src", "app")
+			Expect(os.MkdirAll(projectDir, os.ModePerm)).To(Succeed())
+			Expect(os.Setenv("BP_DOTNET_PROJECT_PATH", "src/app")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_DOTNET_PROJECT_PATH")).To(Succeed())
+		})
+
+		it("looks up the version sources in the project directory", func() {
+			_, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buildpackYMLParser.ParseVersionCall.Receives.Path).To(Equal(filepath.Join(workingDir, "buildpack.yml")))
+			Expect(projectParser.ParseVersionCall.Receives.ProjectDir).To(Equal(projectDir))
+			Expect(projectParser.ParseVersionCall.Receives.WorkingDir).To(Equal(workingDir))
+			Expect(globalJSONParser.ParseSDKCall.Receives.Path).To(Equal(filepath.Join(workingDir, "global.json")))
+		})
+
+		context("when a global.json is in a parent of the project directory", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "src", "global.json"), []byte(`{}`), 0600)).To(Succeed())
+			})
+
+			it("uses the nearest one", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(globalJSONParser.ParseSDKCall.Receives.Path).To(Equal(filepath.Join(workingDir, "src", "global.json")))
+			})
+		})
+
+		context("when a buildpack.yml is in the project directory", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(projectDir, "buildpack.yml"), []byte(`{}`), 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "buildpack.yml"), []byte(`{}`), 0600)).To(Succeed())
+			})
+
+			it("uses it rather than the one in the working directory", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buildpackYMLParser.ParseVersionCall.Receives.Path).To(Equal(filepath.Join(projectDir, "buildpack.yml")))
+			})
+		})
+
+		context("when a buildpack.yml is in a parent of the project directory", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "src", "buildpack.yml"), []byte(`{}`), 0600)).To(Succeed())
+			})
+
+			it("uses the nearest one", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buildpackYMLParser.ParseVersionCall.Receives.Path).To(Equal(filepath.Join(workingDir, "src", "buildpack.yml")))
+			})
+		})
+	})
+
 	context("failure cases", func() {
+		context("when BP_DOTNET_FRAMEWORK_VERSION is not a valid version constraint", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_DOTNET_FRAMEWORK_VERSION", "latest-lts")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_DOTNET_FRAMEWORK_VERSION")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).To(MatchError(ContainSubstring(`invalid BP_DOTNET_FRAMEWORK_VERSION value "latest-lts": expected a version such as 6.0.1 or a constraint such as 6.0.*`)))
+			})
+		})
+
+		context("when BP_DOTNET_PROJECT_PATH is outside of the working directory", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_DOTNET_PROJECT_PATH", "../other-app")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_DOTNET_PROJECT_PATH")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).To(MatchError(fmt.Sprintf(`invalid BP_DOTNET_PROJECT_PATH value "../other-app": must be a directory inside the application directory %s`, workingDir)))
+			})
+		})
+
+		context("when BP_DOTNET_PROJECT_PATH does not exist", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_DOTNET_PROJECT_PATH", "src/missing")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_DOTNET_PROJECT_PATH")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).To(MatchError(fmt.Sprintf(`invalid BP_DOTNET_PROJECT_PATH value "src/missing": %s does not exist`, filepath.Join(workingDir, "src", "missing"))))
+			})
+		})
+
+		context("when BP_DOTNET_PROJECT_PATH is not a directory", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "app.csproj"), nil, 0600)).To(Succeed())
+				Expect(os.Setenv("BP_DOTNET_PROJECT_PATH", "app.csproj")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_DOTNET_PROJECT_PATH")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).To(MatchError(fmt.Sprintf(`invalid BP_DOTNET_PROJECT_PATH value "app.csproj": %s is not a directory`, filepath.Join(workingDir, "app.csproj"))))
+			})
+		})
+
+		context("when BP_DOTNET_RUNTIME_LAUNCH is not a boolean", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_DOTNET_RUNTIME_LAUNCH", "yes please")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_DOTNET_RUNTIME_LAUNCH")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).To(MatchError(ContainSubstring(`failed to parse BP_DOTNET_RUNTIME_LAUNCH value "yes please"`)))
+			})
+		})
+
+		context("when the version file parser fails", func() {
+			it.Before(func() {
+				versionFileParser.ParseVersionCall.Returns.Err = errors.New("failed to parse .dotnet-version")
+			})
+
+			it("returns an error", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).To(MatchError("failed to parse .dotnet-version"))
+			})
+		})
+
+		context("when the .tool-versions parser fails", func() {
+			it.Before(func() {
+				toolVersionsParser.ParseSDKCall.Returns.Err = errors.New("failed to parse .tool-versions")
+			})
+
+			it("returns an error", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).To(MatchError("failed to parse .tool-versions"))
+			})
+		})
+
+		context("when the project parser fails", func() {
+			it.Before(func() {
+				projectParser.ParseVersionCall.Returns.Err = errors.New("failed to parse project file")
+			})
+
+			it("returns an error", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).To(MatchError("failed to parse project file"))
+			})
+		})
+
+		context("when the .deps.json parser fails", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "app.deps.json"), []byte(`{}`), 0600)).To(Succeed())
+				depsJSONParser.ParseVersionCall.Returns.Err = errors.New("failed to parse app.deps.json")
+			})
+
+			it("returns an error", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).To(MatchError("failed to parse app.deps.json"))
+			})
+		})
+
+		context("when the global.json parser fails", func() {
+			it.Before(func() {
+				globalJSONParser.ParseSDKCall.Returns.Err = errors.New("failed to parse global.json")
+			})
+
+			it("returns an error", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).To(MatchError("failed to parse global.json"))
+			})
+		})
+
+		context("when the buildpack.toml cannot be parsed", func() {
+			it.Before(func() {
+				globalJSONParser.ParseSDKCall.Returns.Version = "6.0.402"
+				Expect(os.WriteFile(filepath.Join(cnbDir, "buildpack.toml"), []byte("%%%"), 0600)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+				})
+				Expect(err).To(MatchError(ContainSubstring("expected '.' or '=', but got '%' instead")))
+			})
+		})
+
+		context("when the global.json SDK version is invalid", func() {
+			it.Before(func() {
+				globalJSONParser.ParseSDKCall.Returns.Version = "latest"
+			})
+
+			it("returns an error", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+				})
+				Expect(err).To(MatchError(ContainSubstring(`failed to map global.json SDK version to a runtime version: invalid SDK version "latest"`)))
+			})
+		})
+
 		context("when the buildpack.yml parser fails", func() {
 			it.Before(func() {
 				buildpackYMLParser.ParseVersionCall.Returns.Err = errors.New("failed to parse buildpack.yml")
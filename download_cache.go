@@ -0,0 +1,308 @@
+package dotnetcoreruntime
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/paketo-buildpacks/packit/v2/chronos"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
+)
+
+// DownloadCacheLayerName is the name of the cache layer that holds the
+// downloaded runtime tarballs, next to the layer they are installed into.
+const DownloadCacheLayerName = "dotnet-core-runtime-downloads"
+
+// defaultDownloadCacheSize is the size, in MiB, to which the download cache is
+// pruned when BP_DOTNET_RUNTIME_DOWNLOAD_CACHE_SIZE is unset. It holds a
+// handful of runtime versions.
+const defaultDownloadCacheSize = 256
+
+//go:generate faux --interface Transport --output fakes/transport.go
+type Transport interface {
+	Drop(root, uri string) (io.ReadCloser, error)
+}
+
+// DownloadCache delivers dependencies through a cache of downloaded tarballs,
+// keyed by their SHA256, in the download cache layer next to the layer that
+// the dependency is installed into. A tarball is only downloaded when it is
+// not cached yet, so that reinstalling a runtime, for example after the layer
+// metadata changed, does not cost a download. Dependencies are delivered by
+// the wrapped manager without the cache when the cache layer does not exist,
+// when they have no SHA256, when they are packaged with the buildpack or when
+// a dependency-mapping binding redirects them.
+type DownloadCache struct {
+	manager   DependencyManager
+	transport Transport
+	logger    scribe.Emitter
+	clock     chronos.Clock
+}
+
+func NewDownloadCache(manager DependencyManager, transport Transport, logger scribe.Emitter, clock chronos.Clock) DownloadCache {
+	return DownloadCache{
+		manager:   manager,
+		transport: transport,
+		logger:    logger,
+		clock:     clock,
+	}
+}
+
+func (c DownloadCache) Deliver(dependency postal.Dependency, cnbPath, layerPath, platformPath string) error {
+	cacheDir := filepath.Join(filepath.Dir(layerPath), DownloadCacheLayerName)
+	if info, err := os.Stat(cacheDir); err != nil || !info.IsDir() {
+		return c.manager.Deliver(dependency, cnbPath, layerPath, platformPath)
+	}
+
+	sha := dependencySHA256(dependency)
+	if sha == "" || strings.HasPrefix(dependency.URI, "file://") {
+		return c.manager.Deliver(dependency, cnbPath, layerPath, platformPath)
+	}
+
+	mapped, err := hasDependencyMapping(sha, platformPath)
+	if err != nil {
+		return err
+	}
+
+	if mapped {
+		return c.manager.Deliver(dependency, cnbPath, layerPath, platformPath)
+	}
+
+	path := filepath.Join(cacheDir, sha)
+	if _, err := os.Stat(path); err == nil {
+		c.logger.Subprocess("Using cached download of %s", dependency.URI)
+
+		// Record the use so that the least recently used tarballs are pruned first.
+		now := c.clock.Now()
+		err = os.Chtimes(path, now, now)
+		if err != nil {
+			return err
+		}
+	} else {
+		err = c.download(dependency.URI, cnbPath, path, sha)
+		if err != nil {
+			return fmt.Errorf("failed to fetch dependency: %w", err)
+		}
+	}
+
+	cached := dependency
+	cached.URI = fmt.Sprintf("file:///%s", sha)
+	if cached.Name == "" {
+		cached.Name = filepath.Base(dependency.URI)
+	}
+
+	return c.manager.Deliver(cached, cacheDir, layerPath, platformPath)
+}
+
+func (c DownloadCache) GenerateBillOfMaterials(dependencies ...postal.Dependency) []packit.BOMEntry {
+	return c.manager.GenerateBillOfMaterials(dependencies...)
+}
+
+// download writes the artifact at uri to path, and only keeps it when its
+// SHA256 matches the expected one.
+func (c DownloadCache) download(uri, cnbPath, path, sha string) error {
+	bundle, err := c.transport.Drop(cnbPath, uri)
+	if err != nil {
+		return err
+	}
+	defer bundle.Close()
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".download-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(file, hash), bundle)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != sha {
+		return fmt.Errorf("checksum does not match: expected sha256:%s, got sha256:%s", sha, sum)
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+// dependencySHA256 returns the hex-encoded SHA256 of the dependency's
+// artifact, or an empty string when its checksum uses another algorithm.
+func dependencySHA256(dependency postal.Dependency) string {
+	if dependency.SHA256 != "" { //nolint:staticcheck
+		return strings.ToLower(dependency.SHA256) //nolint:staticcheck
+	}
+
+	checksum := cargo.Checksum(dependency.Checksum)
+	if checksum.Algorithm() != "sha256" {
+		return ""
+	}
+	return strings.ToLower(checksum.Hash())
+}
+
+// hasDependencyMapping reports whether a dependency-mapping binding redirects
+// the artifact with the given SHA256 to another location.
+func hasDependencyMapping(sha, platformDir string) (bool, error) {
+	if !bindingsConfigured(platformDir) {
+		return false, nil
+	}
+
+	bindings, err := servicebindings.NewResolver().Resolve("dependency-mapping", "", platformDir)
+	if err != nil {
+		return false, err
+	}
+
+	for _, binding := range bindings {
+		if _, ok := binding.Entries[sha]; ok {
+			return true, nil
+		}
+		if _, ok := binding.Entries["sha256:"+sha]; ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// bindingsConfigured reports whether service bindings can be looked up: the
+// platform directory is known or the bindings root is set in the environment.
+func bindingsConfigured(platformDir string) bool {
+	return platformDir != "" || os.Getenv("SERVICE_BINDING_ROOT") != "" || os.Getenv("CNB_BINDINGS") != ""
+}
+
+// downloadCacheSize returns the size in bytes to which the download cache is
+// pruned, as set in MiB by BP_DOTNET_RUNTIME_DOWNLOAD_CACHE_SIZE. A size of 0
+// disables the cache.
+func downloadCacheSize() (int64, error) {
+	value, ok := os.LookupEnv("BP_DOTNET_RUNTIME_DOWNLOAD_CACHE_SIZE")
+	if !ok || value == "" {
+		return defaultDownloadCacheSize << 20, nil
+	}
+
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse BP_DOTNET_RUNTIME_DOWNLOAD_CACHE_SIZE value %q: %w", value, err)
+	}
+
+	if size < 0 {
+		return 0, fmt.Errorf("failed to parse BP_DOTNET_RUNTIME_DOWNLOAD_CACHE_SIZE value %q: must not be negative", value)
+	}
+
+	return size << 20, nil
+}
+
+// PruneDownloadCache removes the least recently used tarballs from the
+// download cache directory until their total size is at most maxSize,
+// keeping the tarball with the given SHA256 regardless. Downloads left behind
+// by an interrupted build are always removed. It returns the names of the
+// removed tarballs.
+func PruneDownloadCache(dir string, maxSize int64, keep string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var (
+		tarballs []os.FileInfo
+		total    int64
+	)
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".download-") {
+			err = os.Remove(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		tarballs = append(tarballs, info)
+		total += info.Size()
+	}
+
+	sort.Slice(tarballs, func(i, j int) bool {
+		if tarballs[i].ModTime().Equal(tarballs[j].ModTime()) {
+			return tarballs[i].Name() < tarballs[j].Name()
+		}
+		return tarballs[i].ModTime().Before(tarballs[j].ModTime())
+	})
+
+	var removed []string
+	for _, tarball := range tarballs {
+		if total <= maxSize {
+			break
+		}
+
+		if tarball.Name() == keep {
+			continue
+		}
+
+		err = os.Remove(filepath.Join(dir, tarball.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		total -= tarball.Size()
+		removed = append(removed, tarball.Name())
+	}
+
+	return removed, nil
+}
+
+// retainDownloadCache prunes the download cache layer and returns it, marked
+// as a cache layer, so that it is restored for the next build. The tarball of
+// the dependency that was just installed is never pruned. The layer is
+// removed when the cache is disabled.
+func retainDownloadCache(layer packit.Layer, maxSize int64, keep string, logger scribe.Emitter) ([]packit.Layer, error) {
+	if maxSize == 0 {
+		return nil, os.RemoveAll(layer.Path)
+	}
+
+	if _, err := os.Stat(layer.Path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	removed, err := PruneDownloadCache(layer.Path, maxSize, keep)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(removed) > 0 {
+		logger.Process("Pruning the download cache to %d MiB", maxSize>>20)
+		for _, name := range removed {
+			logger.Subprocess("Removed sha256:%s", name)
+		}
+		logger.Break()
+	}
+
+	layer.Launch, layer.Build, layer.Cache = false, false, true
+
+	return []packit.Layer{layer}, nil
+}
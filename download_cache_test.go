@@ -0,0 +1,230 @@
+package dotnetcoreruntime_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	dotnetcoreruntime "github.com/paketo-buildpacks/dotnet-core-runtime"
+	"github.com/paketo-buildpacks/dotnet-core-runtime/fakes"
+	"github.com/paketo-buildpacks/packit/v2/chronos"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testDownloadCache(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		buffer      *bytes.Buffer
+		layersDir   string
+		layerPath   string
+		cacheDir    string
+		platformDir string
+		artifact    []byte
+		sha         string
+		dependency  postal.Dependency
+
+		dependencyManager *fakes.DependencyManager
+		transport         *fakes.Transport
+		downloadCache     dotnetcoreruntime.DownloadCache
+	)
+
+	it.Before(func() {
+		var err error
+		layersDir, err = os.MkdirTemp("", "layers")
+		Expect(err).NotTo(HaveOccurred())
+
+		platformDir, err = os.MkdirTemp("", "platform")
+		Expect(err).NotTo(HaveOccurred())
+
+		layerPath = filepath.Join(layersDir, "dotnet-core-runtime")
+		cacheDir = filepath.Join(layersDir, "dotnet-core-runtime-downloads")
+		Expect(os.MkdirAll(layerPath, os.ModePerm)).To(Succeed())
+		Expect(os.MkdirAll(cacheDir, os.ModePerm)).To(Succeed())
+
+		artifact = []byte("some-artifact")
+		sum := sha256.Sum256(artifact)
+		sha = hex.EncodeToString(sum[:])
+
+		dependency = postal.Dependency{
+			ID:      "dotnet-runtime",
+			Version: "6.0.13",
+			SHA256:  sha,
+			URI:     "https://deps.example.com/dotnet-runtime-6.0.13.tar.xz",
+		}
+
+		dependencyManager = &fakes.DependencyManager{}
+
+		transport = &fakes.Transport{}
+		transport.DropCall.Stub = func(string, string) (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(artifact)), nil
+		}
+
+		buffer = bytes.NewBuffer(nil)
+		clock := chronos.NewClock(func() time.Time {
+			return time.Date(2023, time.January, 10, 12, 0, 0, 0, time.UTC)
+		})
+		downloadCache = dotnetcoreruntime.NewDownloadCache(dependencyManager, transport, scribe.NewEmitter(buffer), clock)
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layersDir)).To(Succeed())
+		Expect(os.RemoveAll(platformDir)).To(Succeed())
+	})
+
+	it("downloads the tarball into the cache and delivers it from there", func() {
+		err := downloadCache.Deliver(dependency, "cnb-path", layerPath, platformDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(transport.DropCall.Receives.Root).To(Equal("cnb-path"))
+		Expect(transport.DropCall.Receives.Uri).To(Equal("https://deps.example.com/dotnet-runtime-6.0.13.tar.xz"))
+
+		content, err := os.ReadFile(filepath.Join(cacheDir, sha))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal(artifact))
+
+		Expect(dependencyManager.DeliverCall.Receives.Dependency.URI).To(Equal("file:///" + sha))
+		Expect(dependencyManager.DeliverCall.Receives.Dependency.SHA256).To(Equal(sha))
+		Expect(dependencyManager.DeliverCall.Receives.Dependency.Name).To(Equal("dotnet-runtime-6.0.13.tar.xz"))
+		Expect(dependencyManager.DeliverCall.Receives.CnbPath).To(Equal(cacheDir))
+		Expect(dependencyManager.DeliverCall.Receives.LayerPath).To(Equal(layerPath))
+		Expect(dependencyManager.DeliverCall.Receives.PlatformPath).To(Equal(platformDir))
+
+		Expect(buffer.String()).To(BeEmpty())
+	})
+
+	context("when the tarball is already cached", func() {
+		var path string
+
+		it.Before(func() {
+			path = filepath.Join(cacheDir, sha)
+			Expect(os.WriteFile(path, artifact, 0600)).To(Succeed())
+
+			lastUsed := time.Date(2023, time.January, 9, 12, 0, 0, 0, time.UTC)
+			Expect(os.Chtimes(path, lastUsed, lastUsed)).To(Succeed())
+		})
+
+		it("delivers it without downloading it again", func() {
+			err := downloadCache.Deliver(dependency, "cnb-path", layerPath, platformDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(transport.DropCall.CallCount).To(Equal(0))
+			Expect(dependencyManager.DeliverCall.Receives.Dependency.URI).To(Equal("file:///" + sha))
+			Expect(buffer.String()).To(ContainSubstring("Using cached download of https://deps.example.com/dotnet-runtime-6.0.13.tar.xz"))
+
+			info, err := os.Stat(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.ModTime().UTC()).To(Equal(time.Date(2023, time.January, 10, 12, 0, 0, 0, time.UTC)))
+		})
+	})
+
+	context("when the download cache layer does not exist", func() {
+		it.Before(func() {
+			Expect(os.RemoveAll(cacheDir)).To(Succeed())
+		})
+
+		it("delivers the dependency without the cache", func() {
+			err := downloadCache.Deliver(dependency, "cnb-path", layerPath, platformDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(transport.DropCall.CallCount).To(Equal(0))
+			Expect(dependencyManager.DeliverCall.Receives.Dependency).To(Equal(dependency))
+			Expect(dependencyManager.DeliverCall.Receives.CnbPath).To(Equal("cnb-path"))
+		})
+	})
+
+	context("when the dependency is packaged with the buildpack", func() {
+		it.Before(func() {
+			dependency.URI = "file:///dependencies/" + sha + "/dotnet-runtime.tar.xz"
+		})
+
+		it("delivers the dependency without the cache", func() {
+			err := downloadCache.Deliver(dependency, "cnb-path", layerPath, platformDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(transport.DropCall.CallCount).To(Equal(0))
+			Expect(dependencyManager.DeliverCall.Receives.Dependency).To(Equal(dependency))
+		})
+	})
+
+	context("when a dependency-mapping binding redirects the dependency", func() {
+		it.Before(func() {
+			bindingDir := filepath.Join(platformDir, "bindings", "some-mapping")
+			Expect(os.MkdirAll(bindingDir, os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(bindingDir, "type"), []byte("dependency-mapping"), 0600)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(bindingDir, sha), []byte("https://internal.example.com/dotnet-runtime.tar.xz"), 0600)).To(Succeed())
+		})
+
+		it("leaves the redirect to the wrapped manager", func() {
+			err := downloadCache.Deliver(dependency, "cnb-path", layerPath, platformDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(transport.DropCall.CallCount).To(Equal(0))
+			Expect(dependencyManager.DeliverCall.Receives.Dependency).To(Equal(dependency))
+		})
+	})
+
+	context("failure cases", func() {
+		context("when the download fails", func() {
+			it.Before(func() {
+				transport.DropCall.Stub = nil
+				transport.DropCall.Returns.Error = errors.New("connection refused")
+			})
+
+			it("returns an error", func() {
+				err := downloadCache.Deliver(dependency, "cnb-path", layerPath, platformDir)
+				Expect(err).To(MatchError("failed to fetch dependency: connection refused"))
+				Expect(dependencyManager.DeliverCall.CallCount).To(Equal(0))
+			})
+		})
+
+		context("when the downloaded tarball does not match its checksum", func() {
+			it.Before(func() {
+				artifact = []byte("some-other-artifact")
+			})
+
+			it("returns an error and does not cache it", func() {
+				err := downloadCache.Deliver(dependency, "cnb-path", layerPath, platformDir)
+				Expect(err).To(MatchError(ContainSubstring("failed to fetch dependency: checksum does not match: expected sha256:" + sha)))
+
+				entries, err := os.ReadDir(cacheDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(entries).To(BeEmpty())
+			})
+		})
+	})
+
+	context("PruneDownloadCache", func() {
+		it.Before(func() {
+			for i, name := range []string{"a", "b", "c"} {
+				path := filepath.Join(cacheDir, name)
+				Expect(os.WriteFile(path, []byte("0123456789"), 0600)).To(Succeed())
+
+				modTime := time.Now().Add(time.Duration(i-3) * time.Hour)
+				Expect(os.Chtimes(path, modTime, modTime)).To(Succeed())
+			}
+		})
+
+		it("removes the least recently used tarballs until the cache fits", func() {
+			removed, err := dotnetcoreruntime.PruneDownloadCache(cacheDir, 15, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(removed).To(Equal([]string{"a", "b"}))
+		})
+
+		it("keeps the given tarball", func() {
+			removed, err := dotnetcoreruntime.PruneDownloadCache(cacheDir, 15, "a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(removed).To(Equal([]string{"b", "c"}))
+		})
+	})
+}
@@ -0,0 +1,482 @@
+package dotnetcoreruntime_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	dotnetcoreruntime "github.com/paketo-buildpacks/dotnet-core-runtime"
+	"github.com/paketo-buildpacks/dotnet-core-runtime/internal/fakedeps"
+	"github.com/paketo-buildpacks/dotnet-core-runtime/internal/manifest"
+	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/paketo-buildpacks/packit/v2/chronos"
+	"github.com/paketo-buildpacks/packit/v2/draft"
+	packitfakes "github.com/paketo-buildpacks/packit/v2/fakes"
+	"github.com/paketo-buildpacks/packit/v2/fs"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+	"github.com/paketo-buildpacks/packit/v2/sbom"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+// lifecycle runs the detect and build phases in-process against a fixture
+// application, the way the CNB lifecycle would, using the real version
+// resolver, symlinker and planner. Dependencies are synthetic runtimes served
+// by a local fakedeps server, so no network access is needed.
+//
+// Each fixture directory holds a plan.toml whose requirements stand in for
+// those contributed by other buildpacks in the group.
+type lifecycle struct {
+	cnbDir      string
+	layersDir   string
+	platformDir string
+	workingDir  string
+	stack       string
+	logs        *bytes.Buffer
+}
+
+func newLifecycle(server *fakedeps.Server) (lifecycle, error) {
+	l := lifecycle{
+		stack: "io.buildpacks.stacks.jammy",
+		logs:  bytes.NewBuffer(nil),
+	}
+
+	var err error
+	for _, dir := range []*string{&l.cnbDir, &l.layersDir, &l.platformDir} {
+		*dir, err = os.MkdirTemp("", "lifecycle")
+		if err != nil {
+			return lifecycle{}, err
+		}
+	}
+
+	// Stand in for the exec.d binaries that scripts/build.sh would compile so
+	// that the build phase can copy them into the runtime layer.
+	err = os.MkdirAll(filepath.Join(l.cnbDir, "bin"), os.ModePerm)
+	if err != nil {
+		return lifecycle{}, err
+	}
+
+	for _, name := range []string{"verify-runtime", "configure-diagnostics"} {
+		err = os.WriteFile(filepath.Join(l.cnbDir, "bin", name), []byte("#!/bin/sh\n"), 0755)
+		if err != nil {
+			return lifecycle{}, err
+		}
+	}
+
+	path := filepath.Join(l.cnbDir, "buildpack.toml")
+	err = server.RewriteBuildpackTOML("buildpack.toml", path)
+	if err != nil {
+		return lifecycle{}, err
+	}
+
+	// The buildpack version is only filled in when the buildpack is packaged.
+	file, err := os.Open(path)
+	if err != nil {
+		return lifecycle{}, err
+	}
+
+	var config cargo.Config
+	err = cargo.DecodeConfig(file, &config)
+	file.Close()
+	if err != nil {
+		return lifecycle{}, err
+	}
+
+	config.Buildpack.Version = "1.2.3"
+
+	file, err = os.Create(path)
+	if err != nil {
+		return lifecycle{}, err
+	}
+	defer file.Close()
+
+	err = cargo.EncodeConfig(file, config)
+	if err != nil {
+		return lifecycle{}, err
+	}
+
+	return l, nil
+}
+
+// Run copies the fixture into a fresh working directory and runs detect and
+// build against it. The layers directory is kept between runs so that layer
+// reuse can be observed.
+func (l *lifecycle) Run(fixture string) error {
+	err := os.RemoveAll(l.workingDir)
+	if err != nil {
+		return err
+	}
+
+	l.workingDir, err = os.MkdirTemp("", "working-dir")
+	if err != nil {
+		return err
+	}
+
+	err = fs.Copy(filepath.Join("testdata", "e2e", fixture), l.workingDir)
+	if err != nil {
+		return err
+	}
+
+	detectResult, err := dotnetcoreruntime.Detect(dotnetcoreruntime.NewBuildpackYMLParser(), dotnetcoreruntime.NewVersionFileParser(), dotnetcoreruntime.NewToolVersionsParser(scribe.NewEmitter(l.logs)), dotnetcoreruntime.NewGlobalJSONParser(), dotnetcoreruntime.NewDepsJSONParser(), dotnetcoreruntime.NewProjectFileParser(), scribe.NewEmitter(l.logs))(packit.DetectContext{
+		WorkingDir: l.workingDir,
+		CNBPath:    l.cnbDir,
+		Stack:      l.stack,
+	})
+	if err != nil {
+		return err
+	}
+
+	var groupPlan struct {
+		Requires []packit.BuildPlanRequirement `toml:"requires"`
+	}
+	_, err = toml.DecodeFile(filepath.Join(l.workingDir, "plan.toml"), &groupPlan)
+	if err != nil {
+		return err
+	}
+
+	var plan packit.BuildpackPlan
+	for _, requirement := range append(detectResult.Plan.Requires, groupPlan.Requires...) {
+		metadata, _ := requirement.Metadata.(map[string]interface{})
+		plan.Entries = append(plan.Entries, packit.BuildpackPlanEntry{
+			Name:     requirement.Name,
+			Metadata: metadata,
+		})
+	}
+
+	planPath := filepath.Join(l.platformDir, "plan.toml")
+	planFile, err := os.Create(planPath)
+	if err != nil {
+		return err
+	}
+
+	err = toml.NewEncoder(planFile).Encode(plan)
+	planFile.Close()
+	if err != nil {
+		return err
+	}
+
+	// The build phase runs in the application directory and reads the stack
+	// from the environment.
+	pwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	defer os.Chdir(pwd) //nolint:errcheck
+
+	err = os.Chdir(l.workingDir)
+	if err != nil {
+		return err
+	}
+
+	err = os.Setenv("CNB_STACK_ID", l.stack)
+	if err != nil {
+		return err
+	}
+	defer os.Unsetenv("CNB_STACK_ID")
+
+	logger := scribe.NewEmitter(l.logs)
+	exitHandler := &packitfakes.ExitHandler{}
+
+	packit.Build(
+		dotnetcoreruntime.Build(
+			draft.NewPlanner(),
+			dotnetcoreruntime.NewFallbackDependencyManager(dotnetcoreruntime.NewDownloadCache(postal.NewService(cargo.NewTransport()), cargo.NewTransport(), logger, chronos.DefaultClock), logger),
+			dotnetcoreruntime.NewSymlinker(),
+			dotnetcoreruntime.NewRuntimeVersionResolver(logger),
+			dependencySBOMGenerator{},
+			logger,
+			chronos.DefaultClock,
+		),
+		packit.WithArgs([]string{filepath.Join(l.cnbDir, "bin", "build"), l.layersDir, l.platformDir, planPath}),
+		packit.WithExitHandler(exitHandler),
+	)
+
+	return exitHandler.ErrorCall.Receives.Error
+}
+
+func (l lifecycle) Cleanup() error {
+	for _, dir := range []string{l.cnbDir, l.layersDir, l.platformDir, l.workingDir} {
+		err := os.RemoveAll(dir)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type dependencySBOMGenerator struct{}
+
+func (dependencySBOMGenerator) GenerateFromDependency(dependency postal.Dependency, dir string) (sbom.SBOM, error) {
+	return sbom.GenerateFromDependency(dependency, dir)
+}
+
+func testEndToEnd(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		server *fakedeps.Server
+		l      lifecycle
+	)
+
+	it.Before(func() {
+		server = fakedeps.NewServer()
+
+		var err error
+		l, err = newLifecycle(server)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	it.After(func() {
+		Expect(l.Cleanup()).To(Succeed())
+		server.Close()
+	})
+
+	readFile := func(path ...string) string {
+		content, err := os.ReadFile(filepath.Join(path...))
+		Expect(err).NotTo(HaveOccurred())
+		return string(content)
+	}
+
+	layerMetadata := func() (layer struct {
+		Types    map[string]bool        `toml:"types"`
+		Metadata map[string]interface{} `toml:"metadata"`
+	}) {
+		_, err := toml.DecodeFile(filepath.Join(l.layersDir, "dotnet-core-runtime.toml"), &layer)
+		Expect(err).NotTo(HaveOccurred())
+		return layer
+	}
+
+	report := func() (r dotnetcoreruntime.RuntimeReport) {
+		Expect(json.Unmarshal([]byte(readFile(l.layersDir, "dotnet-core-runtime-report", "report.json")), &r)).To(Succeed())
+		return r
+	}
+
+	it("installs the default runtime and links it into the working directory", func() {
+		Expect(l.Run("default")).To(Succeed(), l.logs.String())
+
+		_, sha, err := server.Add("6.0.13")
+		Expect(err).NotTo(HaveOccurred())
+
+		layer := layerMetadata()
+		Expect(layer.Types).To(Equal(map[string]bool{"launch": true, "build": false, "cache": false}))
+		Expect(layer.Metadata).To(Equal(map[string]interface{}{"dependency-checksum": sha}))
+
+		layerPath := filepath.Join(l.layersDir, "dotnet-core-runtime")
+		Expect(filepath.Join(layerPath, "dotnet")).To(BeARegularFile())
+		Expect(readFile(layerPath, "env.launch", "DOTNET_ROOT.override")).To(Equal(filepath.Join(l.workingDir, ".dotnet_root")))
+		Expect(readFile(layerPath, "env.build", "RUNTIME_VERSION.override")).To(Equal("6.0.13"))
+		Expect(filepath.Join(l.layersDir, "dotnet-core-runtime.sbom.cdx.json")).To(BeARegularFile())
+		Expect(filepath.Join(layerPath, "exec.d", "0-verify-runtime")).To(BeARegularFile())
+		Expect(filepath.Join(layerPath, "exec.d", "1-configure-diagnostics")).To(BeARegularFile())
+		Expect(readFile(layerPath, "env.launch", "BPI_DOTNET_RUNTIME_VERSION.override")).To(Equal("6.0.13"))
+
+		runtimeManifest, err := manifest.Read(readFile(layerPath, "env.launch", "BPI_DOTNET_RUNTIME_MANIFEST.override"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runtimeManifest.Version).To(Equal("6.0.13"))
+		Expect(runtimeManifest.Files).To(HaveKey("host/fxr/6.0.13/libhostfxr.so"))
+		Expect(runtimeManifest.Files["host/fxr/6.0.13/libhostfxr.so"].SHA256).To(BeEmpty())
+
+		link, err := os.Readlink(filepath.Join(l.workingDir, ".dotnet_root", "host"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(link).To(Equal(filepath.Join(layerPath, "host")))
+
+		link, err = os.Readlink(filepath.Join(l.workingDir, ".dotnet_root", "shared", "Microsoft.NETCore.App"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(link).To(Equal(filepath.Join(layerPath, "shared", "Microsoft.NETCore.App")))
+
+		Expect(filepath.Join(l.workingDir, ".dotnet_root", "host", "fxr", "6.0.13", "libhostfxr.so")).To(BeARegularFile())
+		Expect(filepath.Join(l.workingDir, ".dotnet_root", "shared", "Microsoft.NETCore.App", "6.0.13", "libcoreclr.so")).To(BeARegularFile())
+
+		Expect(readFile(l.layersDir, "launch.toml")).To(ContainSubstring(`name = ".NET Core Runtime"`))
+		Expect(report().Dependency.Version).To(Equal("6.0.13"))
+	})
+
+	it("rolls forward to the newest available patch", func() {
+		Expect(l.Run("rollforward")).To(Succeed(), l.logs.String())
+
+		Expect(readFile(l.layersDir, "dotnet-core-runtime", "env.build", "RUNTIME_VERSION.override")).To(Equal("6.0.13"))
+		Expect(filepath.Join(l.workingDir, ".dotnet_root", "shared", "Microsoft.NETCore.App", "6.0.13")).To(BeADirectory())
+
+		r := report()
+		Expect(r.VersionSource).To(Equal("runtimeconfig.json"))
+		Expect(r.RollForward).To(Equal([]string{"6.0.0", "6.0.*"}))
+	})
+
+	it("reuses the runtime layer when the selected dependency has not changed", func() {
+		Expect(l.Run("default")).To(Succeed(), l.logs.String())
+		Expect(l.Run("default")).To(Succeed(), l.logs.String())
+
+		Expect(l.logs.String()).To(ContainSubstring("Reusing cached layer " + filepath.Join(l.layersDir, "dotnet-core-runtime")))
+		Expect(report().CacheReused).To(BeTrue())
+		Expect(filepath.Join(l.workingDir, ".dotnet_root", "host", "fxr", "6.0.13", "libhostfxr.so")).To(BeARegularFile())
+	})
+
+	it("reinstalls the runtime from the download cache when the runtime layer is invalidated", func() {
+		Expect(l.Run("default")).To(Succeed(), l.logs.String())
+
+		_, sha, err := server.Add("6.0.13")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(filepath.Join(l.layersDir, "dotnet-core-runtime-downloads", sha)).To(BeARegularFile())
+
+		// Trimming changes the layer metadata, so the layer is not reused.
+		Expect(os.Setenv("BP_DOTNET_RUNTIME_TRIM", "true")).To(Succeed())
+		defer os.Unsetenv("BP_DOTNET_RUNTIME_TRIM")
+
+		l.logs.Reset()
+		Expect(l.Run("default")).To(Succeed(), l.logs.String())
+
+		Expect(l.logs.String()).NotTo(ContainSubstring("Reusing cached layer"))
+		Expect(l.logs.String()).To(ContainSubstring("Using cached download of"))
+		Expect(filepath.Join(l.layersDir, "dotnet-core-runtime", "host", "fxr", "6.0.13", "libhostfxr.so")).To(BeARegularFile())
+	})
+
+	context("when the version is set through buildpack.yml", func() {
+		it("installs that version", func() {
+			Expect(l.Run("with_buildpack_yml")).To(Succeed(), l.logs.String())
+
+			Expect(l.logs.String()).To(ContainSubstring("WARNING: Setting the .NET Framework version through buildpack.yml will be deprecated soon"))
+			Expect(readFile(l.layersDir, "dotnet-core-runtime", "env.build", "RUNTIME_VERSION.override")).To(Equal("7.0.1"))
+			Expect(report().VersionSource).To(Equal("buildpack.yml"))
+		})
+	})
+
+	context("when an SDK is pinned in .tool-versions", func() {
+		it("installs the newest runtime of the line that the SDK ships with", func() {
+			Expect(l.Run("with_tool_versions")).To(Succeed(), l.logs.String())
+
+			Expect(readFile(l.layersDir, "dotnet-core-runtime", "env.build", "RUNTIME_VERSION.override")).To(Equal("7.0.2"))
+			Expect(report().VersionSource).To(Equal(".tool-versions"))
+		})
+	})
+
+	context("when the target framework is inherited from Directory.Build.props", func() {
+		it("rolls forward to the newest patch of that framework", func() {
+			Expect(l.Run("with_directory_build_props")).To(Succeed(), l.logs.String())
+
+			Expect(readFile(l.layersDir, "dotnet-core-runtime", "env.build", "RUNTIME_VERSION.override")).To(Equal("7.0.2"))
+
+			r := report()
+			Expect(r.VersionSource).To(Equal("Directory.Build.props"))
+			Expect(r.RollForward).To(Equal([]string{"7.0.0", "7.0.*"}))
+		})
+	})
+
+	context("when only the .deps.json of a published application is present", func() {
+		it("rolls forward from the runtime it was compiled against", func() {
+			Expect(l.Run("with_deps_json")).To(Succeed(), l.logs.String())
+
+			Expect(readFile(l.layersDir, "dotnet-core-runtime", "env.build", "RUNTIME_VERSION.override")).To(Equal("7.0.2"))
+			Expect(report().VersionSource).To(Equal("app.deps.json"))
+		})
+	})
+
+	context("when an SDK is pinned in global.json", func() {
+		it("installs the newest runtime of the line that the SDK ships with", func() {
+			Expect(l.Run("with_global_json")).To(Succeed(), l.logs.String())
+
+			Expect(readFile(l.layersDir, "dotnet-core-runtime", "env.build", "RUNTIME_VERSION.override")).To(Equal("7.0.2"))
+			Expect(report().VersionSource).To(Equal("global.json"))
+		})
+	})
+
+	context("when BP_DOTNET_ROOT is layer", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_DOTNET_ROOT", "layer")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_DOTNET_ROOT")).To(Succeed())
+		})
+
+		it("points DOTNET_ROOT at the layer and leaves the working directory untouched", func() {
+			Expect(l.Run("default")).To(Succeed(), l.logs.String())
+
+			layerPath := filepath.Join(l.layersDir, "dotnet-core-runtime")
+			Expect(readFile(layerPath, "env.launch", "DOTNET_ROOT.override")).To(Equal(layerPath))
+			Expect(filepath.Join(layerPath, "host", "fxr", "6.0.13", "libhostfxr.so")).To(BeARegularFile())
+			Expect(filepath.Join(l.workingDir, ".dotnet_root")).NotTo(BeAnExistingFile())
+		})
+	})
+
+	context("when BP_DOTNET_FRAMEWORK_VERSION is set", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_DOTNET_FRAMEWORK_VERSION", "7.0.*")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_DOTNET_FRAMEWORK_VERSION")).To(Succeed())
+		})
+
+		it("installs the newest matching version", func() {
+			Expect(l.Run("framework_version")).To(Succeed(), l.logs.String())
+
+			Expect(readFile(l.layersDir, "dotnet-core-runtime", "env.build", "RUNTIME_VERSION.override")).To(Equal("7.0.2"))
+			Expect(filepath.Join(l.workingDir, ".dotnet_root", "host", "fxr", "7.0.2")).To(BeADirectory())
+			Expect(report().VersionSource).To(Equal("BP_DOTNET_FRAMEWORK_VERSION"))
+		})
+	})
+
+	context("when BP_DOTNET_RUNTIME_MIRROR does not have the dependency", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_DOTNET_RUNTIME_MIRROR", server.URL+"/mirror")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_DOTNET_RUNTIME_MIRROR")).To(Succeed())
+		})
+
+		it("falls back to the uri of the dependency", func() {
+			Expect(l.Run("default")).To(Succeed(), l.logs.String())
+
+			uri, _, err := server.Add("6.0.13")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(l.logs.String()).To(ContainSubstring("Failed to download from mirror " + server.URL + "/mirror/dotnet-runtime-6.0.13-linux-x64.tar.gz"))
+			Expect(l.logs.String()).To(ContainSubstring("Downloaded from uri " + uri))
+			Expect(filepath.Join(l.layersDir, "dotnet-core-runtime", "host", "fxr", "6.0.13", "libhostfxr.so")).To(BeARegularFile())
+		})
+	})
+
+	context("when the uri of the dependency is unavailable", func() {
+		it.Before(func() {
+			source, sha, err := server.Add("6.0.13")
+			Expect(err).NotTo(HaveOccurred())
+
+			catalog := filepath.Join(l.platformDir, "catalog.toml")
+			Expect(os.WriteFile(catalog, []byte(fmt.Sprintf(`
+[[dependencies]]
+  id = "dotnet-runtime"
+  sha256 = %q
+  source = %q
+  source_sha256 = %q
+  stacks = [%q]
+  uri = "%s/unavailable/dotnet-runtime-6.0.13.tar.gz"
+  version = "6.0.13"
+`, sha, source, sha, l.stack, server.URL)), 0600)).To(Succeed())
+			Expect(os.Setenv("BP_DOTNET_RUNTIME_CATALOG", catalog)).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_DOTNET_RUNTIME_CATALOG")).To(Succeed())
+		})
+
+		it("installs the runtime from its source", func() {
+			Expect(l.Run("default")).To(Succeed(), l.logs.String())
+
+			source, _, err := server.Add("6.0.13")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(l.logs.String()).To(ContainSubstring("Using dependency catalog from " + filepath.Join(l.platformDir, "catalog.toml") + " (merge mode)"))
+			Expect(l.logs.String()).To(ContainSubstring("Failed to download from uri " + server.URL + "/unavailable/dotnet-runtime-6.0.13.tar.gz"))
+			Expect(l.logs.String()).To(ContainSubstring("Downloaded from source " + source))
+			Expect(filepath.Join(l.layersDir, "dotnet-core-runtime", "host", "fxr", "6.0.13", "libhostfxr.so")).To(BeARegularFile())
+		})
+	})
+}
@@ -0,0 +1,32 @@
+package fakes
+
+import "sync"
+
+type ProjectParser struct {
+	ParseVersionCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			ProjectDir string
+			WorkingDir string
+		}
+		Returns struct {
+			Version string
+			Source  string
+			Err     error
+		}
+		Stub func(string, string) (string, string, error)
+	}
+}
+
+func (f *ProjectParser) ParseVersion(param1 string, param2 string) (string, string, error) {
+	f.ParseVersionCall.mutex.Lock()
+	defer f.ParseVersionCall.mutex.Unlock()
+	f.ParseVersionCall.CallCount++
+	f.ParseVersionCall.Receives.ProjectDir = param1
+	f.ParseVersionCall.Receives.WorkingDir = param2
+	if f.ParseVersionCall.Stub != nil {
+		return f.ParseVersionCall.Stub(param1, param2)
+	}
+	return f.ParseVersionCall.Returns.Version, f.ParseVersionCall.Returns.Source, f.ParseVersionCall.Returns.Err
+}
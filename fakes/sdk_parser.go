@@ -0,0 +1,30 @@
+package fakes
+
+import "sync"
+
+type SDKParser struct {
+	ParseSDKCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Path string
+		}
+		Returns struct {
+			Version     string
+			RollForward string
+			Err         error
+		}
+		Stub func(string) (string, string, error)
+	}
+}
+
+func (f *SDKParser) ParseSDK(param1 string) (string, string, error) {
+	f.ParseSDKCall.mutex.Lock()
+	defer f.ParseSDKCall.mutex.Unlock()
+	f.ParseSDKCall.CallCount++
+	f.ParseSDKCall.Receives.Path = param1
+	if f.ParseSDKCall.Stub != nil {
+		return f.ParseSDKCall.Stub(param1)
+	}
+	return f.ParseSDKCall.Returns.Version, f.ParseSDKCall.Returns.RollForward, f.ParseSDKCall.Returns.Err
+}
@@ -12,27 +12,29 @@ type VersionResolver struct {
 		mutex     sync.Mutex
 		CallCount int
 		Receives  struct {
-			Path  string
-			Entry packit.BuildpackPlanEntry
-			Stack string
+			Path        string
+			Entry       packit.BuildpackPlanEntry
+			Stack       string
+			PlatformDir string
 		}
 		Returns struct {
 			Dependency postal.Dependency
 			Error      error
 		}
-		Stub func(string, packit.BuildpackPlanEntry, string) (postal.Dependency, error)
+		Stub func(string, packit.BuildpackPlanEntry, string, string) (postal.Dependency, error)
 	}
 }
 
-func (f *VersionResolver) Resolve(param1 string, param2 packit.BuildpackPlanEntry, param3 string) (postal.Dependency, error) {
+func (f *VersionResolver) Resolve(param1 string, param2 packit.BuildpackPlanEntry, param3 string, param4 string) (postal.Dependency, error) {
 	f.ResolveCall.mutex.Lock()
 	defer f.ResolveCall.mutex.Unlock()
 	f.ResolveCall.CallCount++
 	f.ResolveCall.Receives.Path = param1
 	f.ResolveCall.Receives.Entry = param2
 	f.ResolveCall.Receives.Stack = param3
+	f.ResolveCall.Receives.PlatformDir = param4
 	if f.ResolveCall.Stub != nil {
-		return f.ResolveCall.Stub(param1, param2, param3)
+		return f.ResolveCall.Stub(param1, param2, param3, param4)
 	}
 	return f.ResolveCall.Returns.Dependency, f.ResolveCall.Returns.Error
 }
@@ -0,0 +1,161 @@
+package dotnetcoreruntime
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+// FallbackDependencyManager delivers a dependency from the first of an
+// ordered list of locations that succeeds: the mirror configured by
+// BP_DOTNET_RUNTIME_MIRROR, the uri of the dependency, then its source. Each
+// location is verified against the checksum that belongs to it, sha256 for the
+// mirror and uri and source_sha256 for the source.
+type FallbackDependencyManager struct {
+	manager DependencyManager
+	logger  scribe.Emitter
+}
+
+func NewFallbackDependencyManager(manager DependencyManager, logger scribe.Emitter) FallbackDependencyManager {
+	return FallbackDependencyManager{
+		manager: manager,
+		logger:  logger,
+	}
+}
+
+type deliveryLocation struct {
+	name       string
+	dependency postal.Dependency
+}
+
+func (m FallbackDependencyManager) Deliver(dependency postal.Dependency, cnbPath, layerPath, platformPath string) error {
+	locations, err := deliveryLocations(dependency, os.Getenv("BP_DOTNET_RUNTIME_MIRROR"))
+	if err != nil {
+		return err
+	}
+
+	var failures []string
+	for i, location := range locations {
+		if i > 0 {
+			err := clearDirectory(layerPath)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := m.manager.Deliver(location.dependency, cnbPath, layerPath, platformPath)
+		if err != nil {
+			m.logger.Subprocess("Failed to download from %s %s: %s", location.name, location.dependency.URI, err)
+			failures = append(failures, fmt.Sprintf("%s %s: %s", location.name, location.dependency.URI, err))
+			continue
+		}
+
+		if i == 0 && location.name == "uri" {
+			m.logger.Debug.Subprocess("Downloaded from %s", location.dependency.URI)
+		} else {
+			m.logger.Subprocess("Downloaded from %s %s", location.name, location.dependency.URI)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("failed to deliver %s %s from any location:\n  %s", dependency.ID, dependency.Version, strings.Join(failures, "\n  "))
+}
+
+func (m FallbackDependencyManager) GenerateBillOfMaterials(dependencies ...postal.Dependency) []packit.BOMEntry {
+	return m.manager.GenerateBillOfMaterials(dependencies...)
+}
+
+// deliveryLocations returns the locations from which the dependency can be
+// delivered, in the order in which they are tried. A location that repeats an
+// earlier one, or a source without a checksum to verify it against, is left
+// out.
+func deliveryLocations(dependency postal.Dependency, mirror string) ([]deliveryLocation, error) {
+	var locations []deliveryLocation
+
+	if mirror != "" {
+		uri, err := mirrorURI(mirror, dependency.URI)
+		if err != nil {
+			return nil, err
+		}
+
+		if uri != "" {
+			mirrored := dependency
+			mirrored.URI = uri
+			locations = append(locations, deliveryLocation{name: "mirror", dependency: mirrored})
+		}
+	}
+
+	locations = append(locations, deliveryLocation{name: "uri", dependency: dependency})
+
+	if dependency.Source != "" && (dependency.SourceSHA256 != "" || dependency.SourceChecksum != "") {
+		source := dependency
+		source.URI = dependency.Source
+		source.SHA256 = dependency.SourceSHA256
+		source.Checksum = dependency.SourceChecksum
+		locations = append(locations, deliveryLocation{name: "source", dependency: source})
+	}
+
+	var unique []deliveryLocation
+	seen := map[string]bool{}
+	for _, location := range locations {
+		key := location.dependency.URI + " " + location.dependency.SHA256 + " " + location.dependency.Checksum
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, location)
+	}
+
+	return unique, nil
+}
+
+// mirrorURI returns the location of the artifact at uri on the given mirror,
+// which serves the same paths under its own base URL. Artifacts that are not
+// downloaded over HTTP, such as those packaged with an offline buildpack, are
+// not mirrored and return an empty string.
+func mirrorURI(mirror, uri string) (string, error) {
+	mirrorURL, err := url.Parse(mirror)
+	if err != nil || (mirrorURL.Scheme != "http" && mirrorURL.Scheme != "https") || mirrorURL.Host == "" {
+		return "", fmt.Errorf("invalid BP_DOTNET_RUNTIME_MIRROR value %q: expected an http or https URL", mirror)
+	}
+
+	artifactURL, err := url.Parse(uri)
+	if err != nil || (artifactURL.Scheme != "http" && artifactURL.Scheme != "https") {
+		return "", nil
+	}
+
+	mirrorURL.Path = strings.TrimSuffix(mirrorURL.Path, "/") + "/" + strings.TrimPrefix(artifactURL.Path, "/")
+	mirrorURL.RawPath = ""
+	mirrorURL.RawQuery = artifactURL.RawQuery
+
+	return mirrorURL.String(), nil
+}
+
+// clearDirectory removes the contents of dir, such as those left behind by a
+// delivery that failed part of the way through, while keeping dir itself.
+func clearDirectory(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	for _, entry := range entries {
+		err := os.RemoveAll(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
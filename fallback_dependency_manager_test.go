@@ -0,0 +1,205 @@
+package dotnetcoreruntime_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	dotnetcoreruntime "github.com/paketo-buildpacks/dotnet-core-runtime"
+	"github.com/paketo-buildpacks/dotnet-core-runtime/fakes"
+	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testFallbackDependencyManager(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		buffer     *bytes.Buffer
+		layerPath  string
+		dependency postal.Dependency
+		delivered  []postal.Dependency
+		failing    map[string]bool
+
+		dependencyManager *fakes.DependencyManager
+		fallbackManager   dotnetcoreruntime.FallbackDependencyManager
+	)
+
+	it.Before(func() {
+		var err error
+		layerPath, err = os.MkdirTemp("", "layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		dependency = postal.Dependency{
+			ID:           "dotnet-runtime",
+			Version:      "6.0.13",
+			SHA256:       "some-sha",
+			URI:          "https://deps.example.com/dotnet-runtime/dotnet-runtime_6.0.13.tar.xz?arch=x64",
+			Source:       "https://download.example.com/dotnet-runtime-6.0.13-linux-x64.tar.gz",
+			SourceSHA256: "some-source-sha",
+		}
+
+		delivered = nil
+		failing = map[string]bool{}
+
+		dependencyManager = &fakes.DependencyManager{}
+		dependencyManager.DeliverCall.Stub = func(dependency postal.Dependency, cnbPath, layerPath, platformPath string) error {
+			delivered = append(delivered, dependency)
+
+			err := os.WriteFile(filepath.Join(layerPath, filepath.Base(dependency.Version)), []byte(dependency.URI), 0600)
+			if err != nil {
+				return err
+			}
+
+			if failing[dependency.URI] {
+				return errors.New("failed to fetch dependency: connection refused")
+			}
+			return nil
+		}
+		dependencyManager.GenerateBillOfMaterialsCall.Returns.BOMEntrySlice = []packit.BOMEntry{{Name: "dotnet-runtime"}}
+
+		buffer = bytes.NewBuffer(nil)
+		fallbackManager = dotnetcoreruntime.NewFallbackDependencyManager(dependencyManager, scribe.NewEmitter(buffer))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerPath)).To(Succeed())
+	})
+
+	it("delivers the dependency from its uri", func() {
+		err := fallbackManager.Deliver(dependency, "cnb-path", layerPath, "platform-path")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(delivered).To(Equal([]postal.Dependency{dependency}))
+		Expect(dependencyManager.DeliverCall.Receives.CnbPath).To(Equal("cnb-path"))
+		Expect(dependencyManager.DeliverCall.Receives.LayerPath).To(Equal(layerPath))
+		Expect(dependencyManager.DeliverCall.Receives.PlatformPath).To(Equal("platform-path"))
+		Expect(buffer.String()).To(BeEmpty())
+	})
+
+	it("generates the bill of materials with the wrapped manager", func() {
+		Expect(fallbackManager.GenerateBillOfMaterials(dependency)).To(Equal([]packit.BOMEntry{{Name: "dotnet-runtime"}}))
+		Expect(dependencyManager.GenerateBillOfMaterialsCall.Receives.Dependencies).To(Equal([]postal.Dependency{dependency}))
+	})
+
+	context("when the uri fails", func() {
+		it.Before(func() {
+			failing[dependency.URI] = true
+		})
+
+		it("falls back to the source, verified against the source checksum", func() {
+			err := fallbackManager.Deliver(dependency, "cnb-path", layerPath, "platform-path")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(delivered).To(HaveLen(2))
+			Expect(delivered[1].URI).To(Equal("https://download.example.com/dotnet-runtime-6.0.13-linux-x64.tar.gz"))
+			Expect(delivered[1].SHA256).To(Equal("some-source-sha"))
+
+			Expect(buffer.String()).To(ContainSubstring("Failed to download from uri https://deps.example.com/dotnet-runtime/dotnet-runtime_6.0.13.tar.xz?arch=x64: failed to fetch dependency: connection refused"))
+			Expect(buffer.String()).To(ContainSubstring("Downloaded from source https://download.example.com/dotnet-runtime-6.0.13-linux-x64.tar.gz"))
+		})
+
+		it("clears what the failed delivery left in the layer", func() {
+			dependency.Version = "partial"
+			failing[dependency.Source] = true
+
+			_ = fallbackManager.Deliver(dependency, "cnb-path", layerPath, "platform-path")
+
+			content, err := os.ReadFile(filepath.Join(layerPath, "partial"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal(dependency.Source))
+		})
+
+		context("when the dependency has no source checksum", func() {
+			it.Before(func() {
+				dependency.SourceSHA256 = ""
+			})
+
+			it("does not try the unverifiable source", func() {
+				err := fallbackManager.Deliver(dependency, "cnb-path", layerPath, "platform-path")
+				Expect(err).To(MatchError(ContainSubstring("failed to deliver dotnet-runtime 6.0.13 from any location")))
+				Expect(delivered).To(HaveLen(1))
+			})
+		})
+
+		context("when every location fails", func() {
+			it.Before(func() {
+				failing[dependency.Source] = true
+			})
+
+			it("returns an error listing every location", func() {
+				err := fallbackManager.Deliver(dependency, "cnb-path", layerPath, "platform-path")
+				Expect(err).To(MatchError(`failed to deliver dotnet-runtime 6.0.13 from any location:
+  uri https://deps.example.com/dotnet-runtime/dotnet-runtime_6.0.13.tar.xz?arch=x64: failed to fetch dependency: connection refused
+  source https://download.example.com/dotnet-runtime-6.0.13-linux-x64.tar.gz: failed to fetch dependency: connection refused`))
+			})
+		})
+	})
+
+	context("when BP_DOTNET_RUNTIME_MIRROR is set", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_DOTNET_RUNTIME_MIRROR", "https://mirror.example.com/paketo/")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_DOTNET_RUNTIME_MIRROR")).To(Succeed())
+		})
+
+		it("delivers the dependency from the mirror first", func() {
+			err := fallbackManager.Deliver(dependency, "cnb-path", layerPath, "platform-path")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(delivered).To(HaveLen(1))
+			Expect(delivered[0].URI).To(Equal("https://mirror.example.com/paketo/dotnet-runtime/dotnet-runtime_6.0.13.tar.xz?arch=x64"))
+			Expect(delivered[0].SHA256).To(Equal("some-sha"))
+			Expect(buffer.String()).To(ContainSubstring("Downloaded from mirror https://mirror.example.com/paketo/dotnet-runtime/dotnet-runtime_6.0.13.tar.xz?arch=x64"))
+		})
+
+		context("when the mirror fails", func() {
+			it.Before(func() {
+				failing["https://mirror.example.com/paketo/dotnet-runtime/dotnet-runtime_6.0.13.tar.xz?arch=x64"] = true
+			})
+
+			it("falls back to the uri", func() {
+				err := fallbackManager.Deliver(dependency, "cnb-path", layerPath, "platform-path")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(delivered).To(HaveLen(2))
+				Expect(delivered[1]).To(Equal(dependency))
+				Expect(buffer.String()).To(ContainSubstring("Failed to download from mirror"))
+				Expect(buffer.String()).To(ContainSubstring("Downloaded from uri " + dependency.URI))
+			})
+		})
+
+		context("when the dependency is packaged with the buildpack", func() {
+			it.Before(func() {
+				dependency.URI = "file:///dependencies/some-sha/dotnet-runtime.tar.xz"
+			})
+
+			it("does not use the mirror", func() {
+				err := fallbackManager.Deliver(dependency, "cnb-path", layerPath, "platform-path")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(delivered).To(Equal([]postal.Dependency{dependency}))
+			})
+		})
+
+		context("when it is not an http URL", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_DOTNET_RUNTIME_MIRROR", "mirror.example.com")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				err := fallbackManager.Deliver(dependency, "cnb-path", layerPath, "platform-path")
+				Expect(err).To(MatchError(`invalid BP_DOTNET_RUNTIME_MIRROR value "mirror.example.com": expected an http or https URL`))
+				Expect(delivered).To(BeEmpty())
+			})
+		})
+	})
+}
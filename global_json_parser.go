@@ -0,0 +1,100 @@
+package dotnetcoreruntime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+type GlobalJSONParser struct{}
+
+func NewGlobalJSONParser() GlobalJSONParser {
+	return GlobalJSONParser{}
+}
+
+// ParseSDK returns the SDK version pinned by sdk.version in the global.json
+// file at the given path, and its sdk.rollForward policy, or empty strings
+// when the file does not exist or does not pin an SDK.
+func (p GlobalJSONParser) ParseSDK(path string) (string, string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", "", nil
+		}
+		return "", "", err
+	}
+
+	var globalJSON struct {
+		SDK struct {
+			Version     string `json:"version"`
+			RollForward string `json:"rollForward"`
+		} `json:"sdk"`
+	}
+
+	err = json.Unmarshal(content, &globalJSON)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return globalJSON.SDK.Version, globalJSON.SDK.RollForward, nil
+}
+
+// RuntimeVersionForSDK returns the runtime version constraint of the first
+// mapping that matches the given SDK version, or an empty string when none
+// does. A mapping matches either a feature band, such as 6.0.4xx, or a version
+// constraint, such as 6.0.*; prerelease SDKs match like their release. An
+// sdk.rollForward policy that lets the SDK roll forward to a newer minor or
+// major version widens the runtime version accordingly.
+func RuntimeVersionForSDK(sdkVersion, rollForward string, mappings []SDKRuntimeMapping) (string, error) {
+	version, err := semver.NewVersion(sdkVersion)
+	if err != nil {
+		return "", fmt.Errorf("invalid SDK version %q: %w", sdkVersion, err)
+	}
+
+	release, err := version.SetPrerelease("")
+	if err != nil {
+		return "", fmt.Errorf("invalid SDK version %q: %w", sdkVersion, err)
+	}
+
+	featureBand := fmt.Sprintf("%d.%d.%dxx", release.Major(), release.Minor(), release.Patch()/100)
+
+	var runtime string
+	for _, mapping := range mappings {
+		if strings.HasSuffix(mapping.SDK, "xx") {
+			if mapping.SDK == featureBand {
+				runtime = mapping.Runtime
+				break
+			}
+			continue
+		}
+
+		constraint, err := semver.NewConstraint(mapping.SDK)
+		if err != nil {
+			return "", fmt.Errorf("invalid SDK constraint %q in sdk-runtime-mappings: %w", mapping.SDK, err)
+		}
+
+		if constraint.Check(&release) {
+			runtime = mapping.Runtime
+			break
+		}
+	}
+
+	if runtime == "" {
+		return "", nil
+	}
+
+	switch strings.ToLower(rollForward) {
+	case "", "disable", "patch", "latestpatch", "feature", "latestfeature":
+		return runtime, nil
+	case "minor", "latestminor":
+		return fmt.Sprintf("%d.*", release.Major()), nil
+	case "major", "latestmajor":
+		return "*", nil
+	default:
+		return "", fmt.Errorf("invalid global.json sdk.rollForward value %q: must be one of disable, patch, feature, minor, major, latestPatch, latestFeature, latestMinor or latestMajor", rollForward)
+	}
+}
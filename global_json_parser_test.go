@@ -0,0 +1,188 @@
+package dotnetcoreruntime_test
+
+import (
+	"os"
+	"testing"
+
+	dotnetcoreruntime "github.com/paketo-buildpacks/dotnet-core-runtime"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testGlobalJSONParser(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		path   string
+		parser dotnetcoreruntime.GlobalJSONParser
+	)
+
+	it.Before(func() {
+		file, err := os.CreateTemp("", "global.json")
+		Expect(err).NotTo(HaveOccurred())
+		defer file.Close()
+
+		_, err = file.WriteString(`{
+  "sdk": {
+    "version": "6.0.402",
+    "rollForward": "latestFeature"
+  }
+}`)
+		Expect(err).NotTo(HaveOccurred())
+
+		path = file.Name()
+
+		parser = dotnetcoreruntime.NewGlobalJSONParser()
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(path)).To(Succeed())
+	})
+
+	context("ParseSDK", func() {
+		it("parses the SDK version and roll forward policy from a global.json file", func() {
+			version, rollForward, err := parser.ParseSDK(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal("6.0.402"))
+			Expect(rollForward).To(Equal("latestFeature"))
+		})
+
+		context("when the global.json file does not pin an SDK", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(path, []byte(`{"msbuild-sdks": {}}`), 0644)).To(Succeed())
+			})
+
+			it("returns an empty version", func() {
+				version, rollForward, err := parser.ParseSDK(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(BeEmpty())
+				Expect(rollForward).To(BeEmpty())
+			})
+		})
+
+		context("when the global.json file does not exist", func() {
+			it.Before(func() {
+				Expect(os.Remove(path)).To(Succeed())
+			})
+
+			it("returns an empty version", func() {
+				version, rollForward, err := parser.ParseSDK(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(BeEmpty())
+				Expect(rollForward).To(BeEmpty())
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the global.json file cannot be read", func() {
+				it.Before(func() {
+					Expect(os.Chmod(path, 0000)).To(Succeed())
+				})
+
+				it.After(func() {
+					Expect(os.Chmod(path, 0644)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, _, err := parser.ParseSDK(path)
+					Expect(err).To(MatchError(ContainSubstring("permission denied")))
+				})
+			})
+
+			context("when the contents of the global.json file are malformed", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(path, []byte("%%%"), 0644)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, _, err := parser.ParseSDK(path)
+					Expect(err).To(MatchError(ContainSubstring("failed to parse " + path)))
+				})
+			})
+		})
+	})
+
+	context("RuntimeVersionForSDK", func() {
+		var mappings []dotnetcoreruntime.SDKRuntimeMapping
+
+		it.Before(func() {
+			mappings = []dotnetcoreruntime.SDKRuntimeMapping{
+				{SDK: "6.0.1xx", Runtime: "6.0.0"},
+				{SDK: "6.0.*", Runtime: "6.0.*"},
+				{SDK: "7.0.1xx", Runtime: "7.0.*"},
+			}
+		})
+
+		it("returns the runtime of the first mapping that matches the feature band", func() {
+			version, err := dotnetcoreruntime.RuntimeVersionForSDK("6.0.100", "", mappings)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal("6.0.0"))
+		})
+
+		it("returns the runtime of the first mapping whose constraint matches", func() {
+			version, err := dotnetcoreruntime.RuntimeVersionForSDK("6.0.402", "", mappings)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal("6.0.*"))
+		})
+
+		it("returns an empty version when no mapping matches", func() {
+			version, err := dotnetcoreruntime.RuntimeVersionForSDK("8.0.100", "", mappings)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(BeEmpty())
+		})
+
+		it("matches prerelease SDKs like their release", func() {
+			version, err := dotnetcoreruntime.RuntimeVersionForSDK("7.0.100-rc.2.22477.23", "", mappings)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal("7.0.*"))
+
+			version, err = dotnetcoreruntime.RuntimeVersionForSDK("6.0.400-preview.1", "", mappings)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal("6.0.*"))
+		})
+
+		context("when the SDK may roll forward", func() {
+			it("keeps the runtime of the mapping when the SDK stays on its minor version", func() {
+				for _, rollForward := range []string{"disable", "patch", "latestPatch", "feature", "latestFeature"} {
+					version, err := dotnetcoreruntime.RuntimeVersionForSDK("6.0.402", rollForward, mappings)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(version).To(Equal("6.0.*"), rollForward)
+				}
+			})
+
+			it("widens the runtime to the major version when the SDK may roll to a newer minor", func() {
+				for _, rollForward := range []string{"minor", "latestMinor"} {
+					version, err := dotnetcoreruntime.RuntimeVersionForSDK("6.0.402", rollForward, mappings)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(version).To(Equal("6.*"), rollForward)
+				}
+			})
+
+			it("allows any runtime when the SDK may roll to a newer major", func() {
+				for _, rollForward := range []string{"major", "latestMajor"} {
+					version, err := dotnetcoreruntime.RuntimeVersionForSDK("6.0.402", rollForward, mappings)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(version).To(Equal("*"), rollForward)
+				}
+			})
+		})
+
+		context("failure cases", func() {
+			it("returns an error when the SDK version is invalid", func() {
+				_, err := dotnetcoreruntime.RuntimeVersionForSDK("latest", "", mappings)
+				Expect(err).To(MatchError(ContainSubstring(`invalid SDK version "latest"`)))
+			})
+
+			it("returns an error when a mapping constraint is invalid", func() {
+				_, err := dotnetcoreruntime.RuntimeVersionForSDK("7.0.100", "", []dotnetcoreruntime.SDKRuntimeMapping{{SDK: "not-a-constraint", Runtime: "7.0.*"}})
+				Expect(err).To(MatchError(ContainSubstring(`invalid SDK constraint "not-a-constraint" in sdk-runtime-mappings`)))
+			})
+
+			it("returns an error when the roll forward policy is invalid", func() {
+				_, err := dotnetcoreruntime.RuntimeVersionForSDK("6.0.402", "sideways", mappings)
+				Expect(err).To(MatchError(ContainSubstring(`invalid global.json sdk.rollForward value "sideways"`)))
+			})
+		})
+	})
+}
@@ -10,9 +10,19 @@ import (
 func TestUnitDotnetCoreRuntime(t *testing.T) {
 	suite := spec.New("dotnet-core-runtime", spec.Report(report.Terminal{}))
 	suite("Build", testBuild)
+	suite("BuildpackTOML", testBuildpackTOML)
 	suite("BuildpackYMLParser", testBuildpackYMLParser)
+	suite("DependencyCatalog", testDependencyCatalog)
+	suite("DepsJSON", testDepsJSON)
 	suite("Detect", testDetect)
+	suite("DownloadCache", testDownloadCache)
+	suite("EndToEnd", testEndToEnd)
+	suite("FallbackDependencyManager", testFallbackDependencyManager)
+	suite("GlobalJSONParser", testGlobalJSONParser)
+	suite("ProjectFileParser", testProjectFileParser)
+	suite("RuntimeConfig", testRuntimeConfig)
 	suite("RuntimeVersionResolver", testRuntimeVersionResolver)
 	suite("Symlinker", testSymlinker)
+	suite("VersionFileParser", testVersionFileParser)
 	suite.Run(t)
 }
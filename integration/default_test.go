@@ -64,6 +64,7 @@ func testDefault(t *testing.T, context spec.G, it spec.S) {
 			var logs fmt.Stringer
 			image, logs, err = pack.WithNoColor().Build.
 				WithPullPolicy("never").
+				WithNetwork(settings.Network).
 				WithBuildpacks(
 					settings.Buildpacks.DotnetCoreRuntime.Online,
 					settings.Buildpacks.BuildPlan.Online,
@@ -88,6 +89,8 @@ func testDefault(t *testing.T, context spec.G, it spec.S) {
 				MatchRegexp(`    RUNTIME_VERSION -> "\d+\.\d+\.\d+"`),
 				"",
 				"  Configuring launch environment",
+				MatchRegexp(`    BPI_DOTNET_RUNTIME_MANIFEST -> "/layers/paketo-buildpacks_dotnet-core-runtime/dotnet-core-runtime/runtime-manifest\.json"`),
+				MatchRegexp(`    BPI_DOTNET_RUNTIME_VERSION -> "\d+\.\d+\.\d+"`),
 				`    DOTNET_ROOT -> "/workspace/.dotnet_root"`,
 			))
 
@@ -150,6 +153,7 @@ func testDefault(t *testing.T, context spec.G, it spec.S) {
 			var logs fmt.Stringer
 			image, logs, err = pack.WithNoColor().Build.
 				WithPullPolicy("never").
+				WithNetwork(settings.Network).
 				WithBuildpacks(
 					settings.Buildpacks.DotnetCoreRuntime.Online,
 					settings.Buildpacks.BuildPlan.Online,
@@ -175,6 +179,8 @@ func testDefault(t *testing.T, context spec.G, it spec.S) {
 				MatchRegexp(`    RUNTIME_VERSION -> "7\.0\.\d+"`),
 				"",
 				"  Configuring launch environment",
+				MatchRegexp(`    BPI_DOTNET_RUNTIME_MANIFEST -> "/layers/paketo-buildpacks_dotnet-core-runtime/dotnet-core-runtime/runtime-manifest\.json"`),
+				MatchRegexp(`    BPI_DOTNET_RUNTIME_VERSION -> "7\.0\.\d+"`),
 				`    DOTNET_ROOT -> "/workspace/.dotnet_root"`,
 			))
 		})
@@ -204,6 +210,7 @@ func testDefault(t *testing.T, context spec.G, it spec.S) {
 			var logs fmt.Stringer
 			_, logs, err = pack.WithNoColor().Build.
 				WithPullPolicy("never").
+				WithNetwork(settings.Network).
 				WithBuildpacks(
 					settings.Buildpacks.DotnetCoreRuntime.Online,
 					settings.Buildpacks.BuildPlan.Online,
@@ -8,7 +8,9 @@ import (
 	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/paketo-buildpacks/dotnet-core-runtime/internal/fakedeps"
 	"github.com/paketo-buildpacks/occam"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
 	"github.com/paketo-buildpacks/packit/v2/postal"
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
@@ -40,6 +42,12 @@ var settings struct {
 			Online string
 		}
 	}
+
+	// Network is the network mode used by builds that download dependencies.
+	// It is empty unless the dependencies are served from the local fake
+	// dependency server, in which case the build containers share the host
+	// network so that they can reach it.
+	Network string
 }
 
 func TestIntegration(t *testing.T) {
@@ -51,16 +59,31 @@ func TestIntegration(t *testing.T) {
 	Expect(json.NewDecoder(file).Decode(&settings.Config)).To(Succeed())
 	Expect(file.Close()).To(Succeed())
 
-	file, err = os.Open("../buildpack.toml")
+	root, err := filepath.Abs("./..")
+	Expect(err).ToNot(HaveOccurred())
+
+	if os.Getenv("INTEGRATION_FAKE_DEPENDENCIES") == "true" {
+		server := fakedeps.NewServer()
+		defer server.Close()
+
+		fakeRoot, err := os.MkdirTemp("", "dotnet-core-runtime")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(fakeRoot)
+
+		Expect(cargo.NewDirectoryDuplicator().Duplicate(root, fakeRoot)).To(Succeed())
+		Expect(server.RewriteBuildpackTOML(filepath.Join(root, "buildpack.toml"), filepath.Join(fakeRoot, "buildpack.toml"))).To(Succeed())
+
+		root = fakeRoot
+		settings.Network = "host"
+	}
+
+	file, err = os.Open(filepath.Join(root, "buildpack.toml"))
 	Expect(err).NotTo(HaveOccurred())
 
 	_, err = toml.NewDecoder(file).Decode(&settings.BuildpackInfo)
 	Expect(err).NotTo(HaveOccurred())
 	Expect(file.Close()).To(Succeed())
 
-	root, err := filepath.Abs("./..")
-	Expect(err).ToNot(HaveOccurred())
-
 	buildpackStore := occam.NewBuildpackStore()
 
 	settings.Buildpacks.DotnetCoreRuntime.Online, err = buildpackStore.Get.
@@ -74,9 +97,24 @@ func TestIntegration(t *testing.T) {
 		Execute(root)
 	Expect(err).ToNot(HaveOccurred())
 
-	settings.Buildpacks.BuildPlan.Online, err = buildpackStore.Get.
-		Execute(settings.Config.BuildPlan)
-	Expect(err).NotTo(HaveOccurred())
+	// INTEGRATION_BUILD_PLAN_BUILDPACK replaces the build-plan buildpack that
+	// is otherwise fetched from GitHub, so that the suite can run without
+	// network access. It names either a packaged buildpack, which is used as
+	// is, or a source directory, which is packaged.
+	buildPlan := settings.Config.BuildPlan
+	if path, ok := os.LookupEnv("INTEGRATION_BUILD_PLAN_BUILDPACK"); ok && path != "" {
+		buildPlan, err = filepath.Abs(path)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	info, err := os.Stat(buildPlan)
+	if err == nil && info.Mode().IsRegular() {
+		settings.Buildpacks.BuildPlan.Online = buildPlan
+	} else {
+		settings.Buildpacks.BuildPlan.Online, err = buildpackStore.Get.
+			Execute(buildPlan)
+		Expect(err).NotTo(HaveOccurred())
+	}
 
 	SetDefaultEventuallyTimeout(5 * time.Second)
 
@@ -67,6 +67,7 @@ func testLayerReuse(t *testing.T, context spec.G, it spec.S) {
 			var logs fmt.Stringer
 			firstImage, logs, err = pack.WithNoColor().Build.
 				WithPullPolicy("never").
+				WithNetwork(settings.Network).
 				WithBuildpacks(
 					settings.Buildpacks.DotnetCoreRuntime.Online,
 					settings.Buildpacks.BuildPlan.Online,
@@ -84,6 +85,7 @@ func testLayerReuse(t *testing.T, context spec.G, it spec.S) {
 
 			secondImage, logs, err = pack.WithNoColor().Build.
 				WithPullPolicy("never").
+				WithNetwork(settings.Network).
 				WithBuildpacks(
 					settings.Buildpacks.DotnetCoreRuntime.Online,
 					settings.Buildpacks.BuildPlan.Online,
@@ -166,6 +168,7 @@ func testLayerReuse(t *testing.T, context spec.G, it spec.S) {
 			var logs fmt.Stringer
 			firstImage, logs, err = pack.WithNoColor().Build.
 				WithPullPolicy("never").
+				WithNetwork(settings.Network).
 				WithBuildpacks(
 					settings.Buildpacks.DotnetCoreRuntime.Online,
 					settings.Buildpacks.BuildPlan.Online,
@@ -186,6 +189,7 @@ func testLayerReuse(t *testing.T, context spec.G, it spec.S) {
 
 			secondImage, logs, err = pack.WithNoColor().Build.
 				WithPullPolicy("never").
+				WithNetwork(settings.Network).
 				WithBuildpacks(
 					settings.Buildpacks.DotnetCoreRuntime.Online,
 					settings.Buildpacks.BuildPlan.Online,
@@ -80,6 +80,8 @@ func testOffline(t *testing.T, context spec.G, it spec.S) {
 				MatchRegexp(`    RUNTIME_VERSION -> "\d+\.\d+\.\d+"`),
 				"",
 				"  Configuring launch environment",
+				MatchRegexp(`    BPI_DOTNET_RUNTIME_MANIFEST -> "/layers/paketo-buildpacks_dotnet-core-runtime/dotnet-core-runtime/runtime-manifest\.json"`),
+				MatchRegexp(`    BPI_DOTNET_RUNTIME_VERSION -> "\d+\.\d+\.\d+"`),
 				`    DOTNET_ROOT -> "/workspace/.dotnet_root"`,
 			))
 
@@ -52,6 +52,7 @@ func testRollForwardLogging(t *testing.T, context spec.G, it spec.S) {
 				var logs fmt.Stringer
 				_, logs, err = pack.WithNoColor().Build.
 					WithPullPolicy("never").
+					WithNetwork(settings.Network).
 					WithBuildpacks(
 						settings.Buildpacks.DotnetCoreRuntime.Online,
 						settings.Buildpacks.BuildPlan.Online,
@@ -77,6 +78,8 @@ func testRollForwardLogging(t *testing.T, context spec.G, it spec.S) {
 					MatchRegexp(`    RUNTIME_VERSION -> "\d+\.\d+\.\d+"`),
 					"",
 					"  Configuring launch environment",
+					MatchRegexp(`    BPI_DOTNET_RUNTIME_MANIFEST -> "/layers/paketo-buildpacks_dotnet-core-runtime/dotnet-core-runtime/runtime-manifest\.json"`),
+					MatchRegexp(`    BPI_DOTNET_RUNTIME_VERSION -> "\d+\.\d+\.\d+"`),
 					`    DOTNET_ROOT -> "/workspace/.dotnet_root"`,
 				))
 			})
@@ -103,6 +106,7 @@ func testRollForwardLogging(t *testing.T, context spec.G, it spec.S) {
 				var logs fmt.Stringer
 				_, logs, err = pack.WithNoColor().Build.
 					WithPullPolicy("never").
+					WithNetwork(settings.Network).
 					WithBuildpacks(
 						settings.Buildpacks.DotnetCoreRuntime.Online,
 						settings.Buildpacks.BuildPlan.Online,
@@ -0,0 +1,14 @@
+package fakedeps_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitFakeDeps(t *testing.T) {
+	suite := spec.New("fakedeps", spec.Report(report.Terminal{}))
+	suite("Server", testServer)
+	suite.Run(t)
+}
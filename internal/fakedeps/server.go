@@ -0,0 +1,114 @@
+package fakedeps
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+)
+
+// Server serves synthetic .NET Core Runtime tarballs over HTTP.
+type Server struct {
+	URL string
+
+	server    *httptest.Server
+	mutex     sync.Mutex
+	artifacts map[string][]byte
+}
+
+func NewServer() *Server {
+	s := &Server{artifacts: map[string][]byte{}}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	s.URL = s.server.URL
+	return s
+}
+
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Add generates a tarball for the given version, if it has not been
+// generated yet, and returns the URI it is served from along with its SHA256.
+func (s *Server) Add(version string) (string, string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	name := fmt.Sprintf("dotnet-runtime-%s-linux-x64.tar.gz", version)
+
+	artifact, ok := s.artifacts[name]
+	if !ok {
+		var err error
+		artifact, err = Tarball(version)
+		if err != nil {
+			return "", "", err
+		}
+		s.artifacts[name] = artifact
+	}
+
+	sum := sha256.Sum256(artifact)
+
+	return fmt.Sprintf("%s/%s", s.URL, name), hex.EncodeToString(sum[:]), nil
+}
+
+// RewriteBuildpackTOML reads the buildpack.toml at src and writes a copy to
+// dst in which every dotnet-runtime dependency is served by this server.
+func (s *Server) RewriteBuildpackTOML(src, dst string) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var config cargo.Config
+	err = cargo.DecodeConfig(file, &config)
+	if err != nil {
+		return err
+	}
+
+	for i, dependency := range config.Metadata.Dependencies {
+		if dependency.ID != "dotnet-runtime" {
+			continue
+		}
+
+		uri, sha, err := s.Add(dependency.Version)
+		if err != nil {
+			return err
+		}
+
+		dependency.URI = uri
+		dependency.SHA256 = sha
+		dependency.Checksum = ""
+		dependency.Source = uri
+		dependency.SourceSHA256 = sha
+		dependency.SourceChecksum = ""
+		dependency.PURL = fmt.Sprintf("pkg:generic/dotnet-runtime@%s?checksum=%s&download_url=%s", dependency.Version, sha, uri)
+		config.Metadata.Dependencies[i] = dependency
+	}
+
+	output, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	return cargo.EncodeConfig(output, config)
+}
+
+func (s *Server) serve(w http.ResponseWriter, req *http.Request) {
+	s.mutex.Lock()
+	artifact, ok := s.artifacts[strings.TrimPrefix(req.URL.Path, "/")]
+	s.mutex.Unlock()
+
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	_, _ = w.Write(artifact)
+}
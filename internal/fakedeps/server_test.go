@@ -0,0 +1,131 @@
+package fakedeps_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/dotnet-core-runtime/internal/fakedeps"
+	"github.com/paketo-buildpacks/dotnet-core-runtime/validation"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testServer(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		server *fakedeps.Server
+	)
+
+	it.Before(func() {
+		server = fakedeps.NewServer()
+	})
+
+	it.After(func() {
+		server.Close()
+	})
+
+	context("Tarball", func() {
+		it("produces the same runtime layout for a version every time", func() {
+			first, err := fakedeps.Tarball("6.0.13")
+			Expect(err).NotTo(HaveOccurred())
+
+			second, err := fakedeps.Tarball("6.0.13")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(first).To(Equal(second))
+
+			gzipReader, err := gzip.NewReader(bytes.NewReader(first))
+			Expect(err).NotTo(HaveOccurred())
+
+			var names []string
+			tarReader := tar.NewReader(gzipReader)
+			for {
+				header, err := tarReader.Next()
+				if err == io.EOF {
+					break
+				}
+				Expect(err).NotTo(HaveOccurred())
+				names = append(names, header.Name)
+			}
+
+			Expect(names).To(ContainElements(
+				"./dotnet",
+				"./host/fxr/6.0.13/libhostfxr.so",
+				"./shared/Microsoft.NETCore.App/6.0.13/",
+				"./shared/Microsoft.NETCore.App/6.0.13/libcoreclr.so",
+			))
+		})
+	})
+
+	context("Add", func() {
+		it("serves the tarball with the returned checksum", func() {
+			uri, sha, err := server.Add("6.0.13")
+			Expect(err).NotTo(HaveOccurred())
+
+			response, err := http.Get(uri)
+			Expect(err).NotTo(HaveOccurred())
+			defer response.Body.Close()
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+
+			content, err := io.ReadAll(response.Body)
+			Expect(err).NotTo(HaveOccurred())
+
+			sum := sha256.Sum256(content)
+			Expect(hex.EncodeToString(sum[:])).To(Equal(sha))
+		})
+
+		it("returns a 404 for unknown artifacts", func() {
+			response, err := http.Get(server.URL + "/dotnet-runtime-1.0.0-linux-x64.tar.gz")
+			Expect(err).NotTo(HaveOccurred())
+			defer response.Body.Close()
+			Expect(response.StatusCode).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	context("RewriteBuildpackTOML", func() {
+		var dir string
+
+		it.Before(func() {
+			var err error
+			dir, err = os.MkdirTemp("", "fakedeps")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		})
+
+		it("points every dependency at the fake server and keeps the metadata consistent", func() {
+			path := filepath.Join(dir, "buildpack.toml")
+			Expect(server.RewriteBuildpackTOML(filepath.Join("..", "..", "buildpack.toml"), path)).To(Succeed())
+
+			violations, err := validation.ValidateFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(violations).To(BeEmpty())
+
+			content, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring(server.URL + "/dotnet-runtime-6.0.13-linux-x64.tar.gz"))
+			Expect(string(content)).NotTo(ContainSubstring("deps.paketo.io"))
+			Expect(string(content)).To(ContainSubstring(`dotnet-runtime = "6.0.*"`))
+		})
+
+		context("failure cases", func() {
+			context("when the source buildpack.toml does not exist", func() {
+				it("returns an error", func() {
+					err := server.RewriteBuildpackTOML(filepath.Join(dir, "missing.toml"), filepath.Join(dir, "buildpack.toml"))
+					Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+				})
+			})
+		})
+	})
+}
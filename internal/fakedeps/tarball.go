@@ -0,0 +1,91 @@
+// Package fakedeps produces small synthetic .NET Core Runtime artifacts, and
+// serves them over HTTP, so that the buildpack can be exercised without
+// access to the real dependency repositories.
+package fakedeps
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"path"
+	"time"
+)
+
+// Tarball returns a gzipped tarball laid out like a .NET Core Runtime
+// distribution for the given version. The contents are deterministic so that
+// the checksum of a version is stable.
+func Tarball(version string) ([]byte, error) {
+	files := []struct {
+		name    string
+		mode    int64
+		content string
+	}{
+		{"dotnet", 0755, fmt.Sprintf("#!/bin/sh\necho \"fake .NET Core Runtime %s\"\n", version)},
+		{"LICENSE.txt", 0644, "fake license\n"},
+		{"ThirdPartyNotices.txt", 0644, "fake third party notices\n"},
+		{path.Join("host", "fxr", version, "libhostfxr.so"), 0755, fmt.Sprintf("fake hostfxr %s\n", version)},
+		{path.Join("shared", "Microsoft.NETCore.App", version, "Microsoft.NETCore.App.deps.json"), 0644, fmt.Sprintf("{\"runtimeTarget\": {\"name\": \".NETCoreApp,Version=v%s\"}}\n", version)},
+		{path.Join("shared", "Microsoft.NETCore.App", version, "libcoreclr.so"), 0755, fmt.Sprintf("fake coreclr %s\n", version)},
+		{path.Join("shared", "Microsoft.NETCore.App", version, "createdump"), 0755, fmt.Sprintf("fake createdump %s\n", version)},
+	}
+
+	modTime := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	buffer := bytes.NewBuffer(nil)
+	gzipWriter := gzip.NewWriter(buffer)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	directories := map[string]bool{}
+	for _, file := range files {
+		var parents []string
+		for dir := path.Dir(file.name); dir != "."; dir = path.Dir(dir) {
+			parents = append([]string{dir}, parents...)
+		}
+
+		for _, dir := range parents {
+			if directories[dir] {
+				continue
+			}
+			directories[dir] = true
+
+			err := tarWriter.WriteHeader(&tar.Header{
+				Name:     "./" + dir + "/",
+				Mode:     0755,
+				Typeflag: tar.TypeDir,
+				ModTime:  modTime,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err := tarWriter.WriteHeader(&tar.Header{
+			Name:     "./" + file.name,
+			Mode:     file.mode,
+			Size:     int64(len(file.content)),
+			Typeflag: tar.TypeReg,
+			ModTime:  modTime,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tarWriter.Write([]byte(file.content))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := tarWriter.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	err = gzipWriter.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,14 @@
+package manifest_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitManifest(t *testing.T) {
+	suite := spec.New("manifest", spec.Report(report.Terminal{}))
+	suite("Manifest", testManifest)
+	suite.Run(t)
+}
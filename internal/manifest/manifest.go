@@ -0,0 +1,135 @@
+// Package manifest records the files of an installed .NET Core Runtime so that
+// the installation can be verified when the container starts. It only depends
+// on the standard library, since it is linked into the exec.d verifier that is
+// copied into every application image.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Name is the name of the file, at the root of the runtime layer, that
+// records the installed runtime version and files.
+const Name = "runtime-manifest.json"
+
+// Manifest records the .NET Core Runtime that was installed into a layer.
+type Manifest struct {
+	Version string          `json:"version"`
+	Files   map[string]File `json:"files"`
+}
+
+// File records the size and permissions of an installed file and, when hashes
+// were requested at build time, its SHA256.
+type File struct {
+	Size   int64       `json:"size"`
+	Mode   fs.FileMode `json:"mode"`
+	SHA256 string      `json:"sha256,omitempty"`
+}
+
+// Write records every regular file in the layer and writes the resulting
+// manifest to the root of the layer. Files are only hashed when hashes is
+// true, since that reads the whole runtime.
+func Write(layerPath, version string, hashes bool) error {
+	manifest := Manifest{
+		Version: version,
+		Files:   map[string]File{},
+	}
+
+	err := filepath.WalkDir(layerPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(layerPath, path)
+		if err != nil {
+			return err
+		}
+
+		if rel == Name {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		file := File{
+			Size: info.Size(),
+			Mode: info.Mode().Perm(),
+		}
+
+		if hashes {
+			file.SHA256, err = FileSHA256(path)
+			if err != nil {
+				return err
+			}
+		}
+
+		manifest.Files[filepath.ToSlash(rel)] = file
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate runtime manifest: %w", err)
+	}
+
+	file, err := os.Create(filepath.Join(layerPath, Name))
+	if err != nil {
+		return fmt.Errorf("failed to write runtime manifest: %w", err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to write runtime manifest: %w", err)
+	}
+
+	return nil
+}
+
+// Read reads the manifest at the given path.
+func Read(path string) (Manifest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("failed to read runtime manifest: %w", err)
+	}
+	defer file.Close()
+
+	var manifest Manifest
+	err = json.NewDecoder(file).Decode(&manifest)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("failed to decode runtime manifest: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// FileSHA256 returns the hex encoded SHA256 checksum of the file at path.
+func FileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,101 @@
+package manifest_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/dotnet-core-runtime/internal/manifest"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testManifest(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerPath string
+	)
+
+	it.Before(func() {
+		var err error
+		layerPath, err = os.MkdirTemp("", "layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(layerPath, "host", "fxr", "6.0.13"), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(layerPath, "host", "fxr", "6.0.13", "libhostfxr.so"), []byte("hostfxr"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(layerPath, "dotnet"), []byte("dotnet-host"), 0755)).To(Succeed())
+		Expect(os.Symlink("dotnet", filepath.Join(layerPath, "dotnet-link"))).To(Succeed())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerPath)).To(Succeed())
+	})
+
+	it("records the size and permissions of every regular file", func() {
+		Expect(manifest.Write(layerPath, "6.0.13", false)).To(Succeed())
+
+		m, err := manifest.Read(filepath.Join(layerPath, manifest.Name))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m).To(Equal(manifest.Manifest{
+			Version: "6.0.13",
+			Files: map[string]manifest.File{
+				"dotnet":                        {Size: 11, Mode: 0755},
+				"host/fxr/6.0.13/libhostfxr.so": {Size: 7, Mode: 0644},
+			},
+		}))
+	})
+
+	context("when hashes are requested", func() {
+		it("records the SHA256 of every regular file as well", func() {
+			Expect(manifest.Write(layerPath, "6.0.13", true)).To(Succeed())
+
+			m, err := manifest.Read(filepath.Join(layerPath, manifest.Name))
+			Expect(err).NotTo(HaveOccurred())
+
+			sum := sha256.Sum256([]byte("hostfxr"))
+			Expect(m.Files["host/fxr/6.0.13/libhostfxr.so"]).To(Equal(manifest.File{
+				Size:   7,
+				Mode:   0644,
+				SHA256: hex.EncodeToString(sum[:]),
+			}))
+		})
+	})
+
+	context("when a manifest already exists", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(layerPath, manifest.Name), []byte("{}"), 0644)).To(Succeed())
+		})
+
+		it("does not record it", func() {
+			Expect(manifest.Write(layerPath, "6.0.13", false)).To(Succeed())
+
+			m, err := manifest.Read(filepath.Join(layerPath, manifest.Name))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.Files).NotTo(HaveKey(manifest.Name))
+		})
+	})
+
+	context("failure cases", func() {
+		context("when the manifest does not exist", func() {
+			it("returns an error", func() {
+				_, err := manifest.Read(filepath.Join(layerPath, manifest.Name))
+				Expect(err).To(MatchError(ContainSubstring("failed to read runtime manifest")))
+			})
+		})
+
+		context("when the manifest cannot be decoded", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(layerPath, manifest.Name), []byte("%%%"), 0644)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := manifest.Read(filepath.Join(layerPath, manifest.Name))
+				Expect(err).To(MatchError(ContainSubstring("failed to decode runtime manifest")))
+			})
+		})
+	})
+}
@@ -0,0 +1,406 @@
+package dotnetcoreruntime
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+// ProjectFileParser reads the runtime version that a .NET project file
+// targets. It evaluates the properties of the project, including those
+// inherited from Directory.Build.props and Directory.Build.targets, without
+// running MSBuild.
+type ProjectFileParser struct{}
+
+func NewProjectFileParser() ProjectFileParser {
+	return ProjectFileParser{}
+}
+
+// ParseVersion finds the project file in the project directory and returns
+// the runtime version it targets, along with the path, relative to the working
+// directory, of the file that defines it. Directory.Build.props and
+// Directory.Build.targets files are looked up from the project directory up
+// to the working directory. Both are empty when there is no project file or
+// it does not target .NET Core.
+func (p ProjectFileParser) ParseVersion(projectDir, workingDir string) (string, string, error) {
+	var projects []string
+	for _, pattern := range []string{"*.csproj", "*.fsproj", "*.vbproj"} {
+		matches, err := filepath.Glob(filepath.Join(projectDir, pattern))
+		if err != nil {
+			return "", "", err
+		}
+		projects = append(projects, matches...)
+	}
+
+	if len(projects) == 0 {
+		return "", "", nil
+	}
+	sort.Strings(projects)
+
+	evaluation, err := evaluateProject(projects[0], workingDir)
+	if err != nil {
+		return "", "", err
+	}
+
+	version, definedIn := evaluation.runtimeVersion()
+	if version == "" {
+		return "", "", nil
+	}
+
+	source, err := filepath.Rel(workingDir, definedIn)
+	if err != nil {
+		return "", "", err
+	}
+
+	return version, source, nil
+}
+
+type msbuildNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr    `xml:",any,attr"`
+	Content  string        `xml:",chardata"`
+	Children []msbuildNode `xml:",any"`
+}
+
+func (n msbuildNode) attr(name string) string {
+	for _, attr := range n.Attrs {
+		if strings.EqualFold(attr.Name.Local, name) {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+type msbuildProperty struct {
+	value     string
+	definedIn string
+}
+
+// projectEvaluation holds the properties of a project in the order that
+// MSBuild evaluates them: Directory.Build.props, the project file, then
+// Directory.Build.targets, each following their own imports.
+type projectEvaluation struct {
+	rootDir     string
+	projectPath string
+	properties  map[string]msbuildProperty
+	visited     map[string]bool
+}
+
+func evaluateProject(projectPath, rootDir string) (projectEvaluation, error) {
+	e := projectEvaluation{
+		rootDir:     rootDir,
+		projectPath: projectPath,
+		properties:  map[string]msbuildProperty{},
+		visited:     map[string]bool{},
+	}
+
+	projectDir := filepath.Dir(projectPath)
+
+	if path := e.fileAbove("Directory.Build.props", projectDir); path != "" {
+		err := e.evaluate(path)
+		if err != nil {
+			return projectEvaluation{}, err
+		}
+	}
+
+	err := e.evaluate(projectPath)
+	if err != nil {
+		return projectEvaluation{}, err
+	}
+
+	if e.property("ImportDirectoryBuildTargets") != "false" {
+		if path := e.fileAbove("Directory.Build.targets", projectDir); path != "" {
+			err := e.evaluate(path)
+			if err != nil {
+				return projectEvaluation{}, err
+			}
+		}
+	}
+
+	return e, nil
+}
+
+func (e projectEvaluation) evaluate(path string) error {
+	if e.visited[path] {
+		return nil
+	}
+	e.visited[path] = true
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var project msbuildNode
+	err = xml.Unmarshal(content, &project)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return e.evaluateChildren(path, project.Children)
+}
+
+func (e projectEvaluation) evaluateChildren(path string, nodes []msbuildNode) error {
+	for _, node := range nodes {
+		if !e.condition(path, node.attr("Condition")) {
+			continue
+		}
+
+		switch node.XMLName.Local {
+		case "PropertyGroup":
+			for _, property := range node.Children {
+				if !e.condition(path, property.attr("Condition")) {
+					continue
+				}
+
+				e.properties[strings.ToLower(property.XMLName.Local)] = msbuildProperty{
+					value:     e.expand(path, strings.TrimSpace(property.Content)),
+					definedIn: path,
+				}
+			}
+
+		case "ImportGroup":
+			err := e.evaluateChildren(path, node.Children)
+			if err != nil {
+				return err
+			}
+
+		case "Import":
+			importPath := e.expand(path, node.attr("Project"))
+			if importPath == "" || strings.Contains(importPath, "$(") {
+				continue
+			}
+
+			if !filepath.IsAbs(importPath) {
+				importPath = filepath.Join(filepath.Dir(path), importPath)
+			}
+			importPath = filepath.Clean(importPath)
+
+			if !e.withinRoot(importPath) {
+				continue
+			}
+
+			if _, err := os.Stat(importPath); err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					continue
+				}
+				return err
+			}
+
+			err := e.evaluate(importPath)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (e projectEvaluation) property(name string) string {
+	return e.properties[strings.ToLower(name)].value
+}
+
+var (
+	propertyReference = regexp.MustCompile(`\$\(([A-Za-z_][A-Za-z0-9_\-]*)\)`)
+	fileAboveFunction = regexp.MustCompile(`\$\(\[MSBuild\]::(GetPathOfFileAbove|GetDirectoryNameOfFileAbove)\(([^()]*)\)\)`)
+)
+
+// expand replaces property references and the GetPathOfFileAbove and
+// GetDirectoryNameOfFileAbove property functions, which Directory.Build.props
+// files use to import the one above them. Other property functions are left
+// unexpanded.
+func (e projectEvaluation) expand(path, value string) string {
+	value = propertyReference.ReplaceAllStringFunc(value, func(reference string) string {
+		name := propertyReference.FindStringSubmatch(reference)[1]
+
+		switch strings.ToLower(name) {
+		case "msbuildthisfiledirectory":
+			return filepath.Dir(path) + string(filepath.Separator)
+		case "msbuildthisfile":
+			return filepath.Base(path)
+		case "msbuildprojectdirectory":
+			return filepath.Dir(e.projectPath)
+		case "msbuildprojectname":
+			return strings.TrimSuffix(filepath.Base(e.projectPath), filepath.Ext(e.projectPath))
+		}
+
+		return e.property(name)
+	})
+
+	return fileAboveFunction.ReplaceAllStringFunc(value, func(call string) string {
+		matches := fileAboveFunction.FindStringSubmatch(call)
+
+		var args []string
+		for _, arg := range strings.Split(matches[2], ",") {
+			args = append(args, strings.Trim(strings.TrimSpace(arg), `'"`))
+		}
+
+		switch matches[1] {
+		case "GetPathOfFileAbove":
+			startDir := filepath.Dir(path)
+			if len(args) > 1 && args[1] != "" {
+				startDir = args[1]
+			}
+			return e.fileAbove(args[0], startDir)
+
+		default:
+			if len(args) < 2 {
+				return ""
+			}
+			if found := e.fileAbove(args[1], args[0]); found != "" {
+				return filepath.Dir(found)
+			}
+			return ""
+		}
+	})
+}
+
+// fileAbove returns the path of the first file with the given name in dir or
+// one of its parents, without leaving the root directory.
+func (e projectEvaluation) fileAbove(name, dir string) string {
+	dir = filepath.Clean(dir)
+	for e.withinRoot(dir) {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	return ""
+}
+
+func (e projectEvaluation) withinRoot(path string) bool {
+	rel, err := filepath.Rel(e.rootDir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+var (
+	conditionComparison = regexp.MustCompile(`^'([^']*)'\s*(==|!=)\s*'([^']*)'$`)
+	conditionExists     = regexp.MustCompile(`^(!?)\s*Exists\(\s*'([^']*)'\s*\)$`)
+)
+
+// condition evaluates the subset of MSBuild conditions that property and
+// import declarations commonly use: string comparisons and Exists, combined
+// with "and" or "or". Conditions outside of that subset are treated as false.
+func (e projectEvaluation) condition(path, condition string) bool {
+	condition = strings.TrimSpace(condition)
+	if condition == "" {
+		return true
+	}
+
+	if parts := splitCondition(condition, " or "); len(parts) > 1 {
+		for _, part := range parts {
+			if e.condition(path, part) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if parts := splitCondition(condition, " and "); len(parts) > 1 {
+		for _, part := range parts {
+			if !e.condition(path, part) {
+				return false
+			}
+		}
+		return true
+	}
+
+	condition = e.expand(path, condition)
+
+	if matches := conditionComparison.FindStringSubmatch(condition); matches != nil {
+		equal := strings.EqualFold(matches[1], matches[3])
+		return equal == (matches[2] == "==")
+	}
+
+	if matches := conditionExists.FindStringSubmatch(condition); matches != nil {
+		target := matches[2]
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		_, err := os.Stat(target)
+		return (err == nil) == (matches[1] == "")
+	}
+
+	return false
+}
+
+func splitCondition(condition, operator string) []string {
+	var parts []string
+	lower := strings.ToLower(condition)
+	for {
+		index := strings.Index(lower, operator)
+		if index < 0 {
+			break
+		}
+		parts = append(parts, condition[:index])
+		condition, lower = condition[index+len(operator):], lower[index+len(operator):]
+	}
+	return append(parts, condition)
+}
+
+var targetFrameworkMoniker = regexp.MustCompile(`^net(?:coreapp)?(\d+\.\d+)(?:-.*)?$`)
+
+// runtimeVersion returns the runtime version that the evaluated project
+// targets and the file that defines it. RuntimeFrameworkVersion takes
+// precedence over TargetFramework, which takes precedence over the newest
+// .NET Core framework listed in TargetFrameworks.
+func (e projectEvaluation) runtimeVersion() (string, string) {
+	if property, ok := e.properties["runtimeframeworkversion"]; ok && property.value != "" {
+		return property.value, property.definedIn
+	}
+
+	if property, ok := e.properties["targetframework"]; ok && property.value != "" {
+		if version := frameworkRuntimeVersion(property.value); version != "" {
+			return version, property.definedIn
+		}
+		return "", ""
+	}
+
+	property, ok := e.properties["targetframeworks"]
+	if !ok {
+		return "", ""
+	}
+
+	var newest *semver.Version
+	for _, framework := range strings.Split(property.value, ";") {
+		version, err := semver.NewVersion(frameworkRuntimeVersion(strings.TrimSpace(framework)))
+		if err != nil {
+			continue
+		}
+		if newest == nil || version.GreaterThan(newest) {
+			newest = version
+		}
+	}
+
+	if newest == nil {
+		return "", ""
+	}
+
+	return newest.String(), property.definedIn
+}
+
+// frameworkRuntimeVersion turns a target framework moniker such as net6.0 or
+// netcoreapp3.1 into the lowest runtime version that satisfies it. Monikers
+// of the .NET Framework and .NET Standard return an empty string.
+func frameworkRuntimeVersion(framework string) string {
+	matches := targetFrameworkMoniker.FindStringSubmatch(strings.ToLower(framework))
+	if matches == nil {
+		return ""
+	}
+	return matches[1] + ".0"
+}
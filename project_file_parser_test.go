@@ -0,0 +1,316 @@
+package dotnetcoreruntime_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	dotnetcoreruntime "github.com/paketo-buildpacks/dotnet-core-runtime"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testProjectFileParser(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		rootDir    string
+		workingDir string
+		parser     dotnetcoreruntime.ProjectFileParser
+	)
+
+	write := func(path, content string) {
+		Expect(os.MkdirAll(filepath.Dir(path), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(content), 0600)).To(Succeed())
+	}
+
+	it.Before(func() {
+		var err error
+		rootDir, err = os.MkdirTemp("", "root")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir = filepath.Join(rootDir, "workspace")
+		write(filepath.Join(workingDir, "app.csproj"), `<Project Sdk="Microsoft.NET.Sdk.Web">
+  <PropertyGroup>
+    <TargetFramework>net6.0</TargetFramework>
+  </PropertyGroup>
+</Project>`)
+
+		parser = dotnetcoreruntime.NewProjectFileParser()
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(rootDir)).To(Succeed())
+	})
+
+	context("ParseVersion", func() {
+		it("returns the runtime version of the target framework and the project file", func() {
+			version, source, err := parser.ParseVersion(workingDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal("6.0.0"))
+			Expect(source).To(Equal("app.csproj"))
+		})
+
+		context("when there is no project file", func() {
+			it.Before(func() {
+				Expect(os.Remove(filepath.Join(workingDir, "app.csproj"))).To(Succeed())
+			})
+
+			it("returns an empty version", func() {
+				version, source, err := parser.ParseVersion(workingDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(BeEmpty())
+				Expect(source).To(BeEmpty())
+			})
+		})
+
+		context("when the project does not target .NET Core", func() {
+			it.Before(func() {
+				Expect(os.Remove(filepath.Join(workingDir, "app.csproj"))).To(Succeed())
+				write(filepath.Join(workingDir, "app.fsproj"), `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFramework>netstandard2.0</TargetFramework>
+  </PropertyGroup>
+</Project>`)
+			})
+
+			it("returns an empty version", func() {
+				version, _, err := parser.ParseVersion(workingDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(BeEmpty())
+			})
+		})
+
+		context("when the project sets RuntimeFrameworkVersion", func() {
+			it.Before(func() {
+				Expect(os.Remove(filepath.Join(workingDir, "app.csproj"))).To(Succeed())
+				write(filepath.Join(workingDir, "app.vbproj"), `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFramework>netcoreapp3.1</TargetFramework>
+    <RuntimeFrameworkVersion>3.1.4</RuntimeFrameworkVersion>
+  </PropertyGroup>
+</Project>`)
+			})
+
+			it("returns that version", func() {
+				version, source, err := parser.ParseVersion(workingDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(Equal("3.1.4"))
+				Expect(source).To(Equal("app.vbproj"))
+			})
+		})
+
+		context("when the project targets several frameworks", func() {
+			it.Before(func() {
+				write(filepath.Join(workingDir, "app.csproj"), `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFrameworks>net48;net7.0;net6.0-windows</TargetFrameworks>
+  </PropertyGroup>
+</Project>`)
+			})
+
+			it("returns the runtime version of the newest .NET Core framework", func() {
+				version, _, err := parser.ParseVersion(workingDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(Equal("7.0.0"))
+			})
+		})
+
+		context("when the target framework is inherited from Directory.Build.props", func() {
+			it.Before(func() {
+				write(filepath.Join(workingDir, "app.csproj"), `<Project Sdk="Microsoft.NET.Sdk.Web">
+  <PropertyGroup>
+    <OutputType>Exe</OutputType>
+  </PropertyGroup>
+</Project>`)
+				write(filepath.Join(workingDir, "Directory.Build.props"), `<Project>
+  <PropertyGroup>
+    <DotnetVersion>7.0</DotnetVersion>
+    <TargetFramework>net$(DotnetVersion)</TargetFramework>
+  </PropertyGroup>
+</Project>`)
+			})
+
+			it("returns the inherited version with the defining file as the source", func() {
+				version, source, err := parser.ParseVersion(workingDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(Equal("7.0.0"))
+				Expect(source).To(Equal("Directory.Build.props"))
+			})
+
+			context("when Directory.Build.props imports other files", func() {
+				it.Before(func() {
+					write(filepath.Join(workingDir, "Directory.Build.props"), `<Project>
+  <Import Project="$(MSBuildThisFileDirectory)eng/Versions.props" />
+  <PropertyGroup>
+    <TargetFramework>net$(DotnetVersion)</TargetFramework>
+  </PropertyGroup>
+  <PropertyGroup Condition="'$(TargetFramework)' == 'net6.0'">
+    <RuntimeFrameworkVersion>$(Runtime6Version)</RuntimeFrameworkVersion>
+  </PropertyGroup>
+  <PropertyGroup Condition="'$(TargetFramework)' == 'net7.0'">
+    <RuntimeFrameworkVersion>$(Runtime7Version)</RuntimeFrameworkVersion>
+  </PropertyGroup>
+</Project>`)
+					write(filepath.Join(workingDir, "eng", "Versions.props"), `<Project>
+  <Import Project="$([MSBuild]::GetPathOfFileAbove('Common.props', '$(MSBuildThisFileDirectory)../'))" />
+  <PropertyGroup>
+    <Runtime6Version>6.0.1</Runtime6Version>
+    <Runtime7Version>7.0.1</Runtime7Version>
+  </PropertyGroup>
+</Project>`)
+					write(filepath.Join(workingDir, "Common.props"), `<Project>
+  <PropertyGroup>
+    <DotnetVersion>7.0</DotnetVersion>
+  </PropertyGroup>
+</Project>`)
+				})
+
+				it("evaluates the whole import chain", func() {
+					version, source, err := parser.ParseVersion(workingDir, workingDir)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(version).To(Equal("7.0.1"))
+					Expect(source).To(Equal("Directory.Build.props"))
+				})
+			})
+
+			context("when the project file overrides it", func() {
+				it.Before(func() {
+					write(filepath.Join(workingDir, "app.csproj"), `<Project Sdk="Microsoft.NET.Sdk.Web">
+  <PropertyGroup>
+    <TargetFramework>net6.0</TargetFramework>
+  </PropertyGroup>
+</Project>`)
+				})
+
+				it("returns the version of the project file", func() {
+					version, source, err := parser.ParseVersion(workingDir, workingDir)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(version).To(Equal("6.0.0"))
+					Expect(source).To(Equal("app.csproj"))
+				})
+			})
+
+			context("when Directory.Build.targets overrides it", func() {
+				it.Before(func() {
+					write(filepath.Join(workingDir, "Directory.Build.targets"), `<Project>
+  <ImportGroup>
+    <Import Project="eng/Versions.targets" Condition="Exists('eng/Versions.targets')" />
+    <Import Project="eng/Missing.targets" Condition="Exists('eng/Missing.targets')" />
+  </ImportGroup>
+</Project>`)
+					write(filepath.Join(workingDir, "eng", "Versions.targets"), `<Project>
+  <PropertyGroup>
+    <RuntimeFrameworkVersion Condition="'$(MSBuildProjectName)' == 'app' and '$(TargetFramework)' != ''">7.0.2</RuntimeFrameworkVersion>
+  </PropertyGroup>
+</Project>`)
+				})
+
+				it("returns the version of the targets file", func() {
+					version, source, err := parser.ParseVersion(workingDir, workingDir)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(version).To(Equal("7.0.2"))
+					Expect(source).To(Equal(filepath.Join("eng", "Versions.targets")))
+				})
+
+				context("when the project disables the Directory.Build.targets import", func() {
+					it.Before(func() {
+						write(filepath.Join(workingDir, "app.csproj"), `<Project Sdk="Microsoft.NET.Sdk.Web">
+  <PropertyGroup>
+    <ImportDirectoryBuildTargets>false</ImportDirectoryBuildTargets>
+  </PropertyGroup>
+</Project>`)
+					})
+
+					it("ignores it", func() {
+						version, _, err := parser.ParseVersion(workingDir, workingDir)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(version).To(Equal("7.0.0"))
+					})
+				})
+			})
+
+			context("when a condition cannot be evaluated", func() {
+				it.Before(func() {
+					write(filepath.Join(workingDir, "app.csproj"), `<Project Sdk="Microsoft.NET.Sdk.Web">
+  <PropertyGroup Condition="$([System.OperatingSystem]::IsWindows())">
+    <TargetFramework>net6.0-windows</TargetFramework>
+  </PropertyGroup>
+</Project>`)
+				})
+
+				it("does not apply the properties it guards", func() {
+					version, _, err := parser.ParseVersion(workingDir, workingDir)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(version).To(Equal("7.0.0"))
+				})
+			})
+		})
+
+		context("when the project is in a subdirectory of the working directory", func() {
+			it.Before(func() {
+				Expect(os.Rename(filepath.Join(workingDir, "app.csproj"), filepath.Join(workingDir, "app.csproj.bak"))).To(Succeed())
+				write(filepath.Join(workingDir, "src", "app", "app.csproj"), `<Project Sdk="Microsoft.NET.Sdk.Web" />`)
+				write(filepath.Join(workingDir, "src", "Directory.Build.props"), `<Project>
+  <Import Project="$([MSBuild]::GetPathOfFileAbove('Directory.Build.props', '$(MSBuildThisFileDirectory)../'))" />
+  <PropertyGroup Condition="'$(TargetFramework)' == 'net7.0'">
+    <RuntimeFrameworkVersion>7.0.1</RuntimeFrameworkVersion>
+  </PropertyGroup>
+</Project>`)
+				write(filepath.Join(workingDir, "Directory.Build.props"), `<Project>
+  <PropertyGroup>
+    <TargetFramework>net7.0</TargetFramework>
+  </PropertyGroup>
+</Project>`)
+			})
+
+			it("evaluates the Directory.Build.props files up to the working directory", func() {
+				version, source, err := parser.ParseVersion(filepath.Join(workingDir, "src", "app"), workingDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(Equal("7.0.1"))
+				Expect(source).To(Equal(filepath.Join("src", "Directory.Build.props")))
+			})
+		})
+
+		context("when a Directory.Build.props is outside of the working directory", func() {
+			it.Before(func() {
+				write(filepath.Join(rootDir, "Directory.Build.props"), `<Project>
+  <PropertyGroup>
+    <RuntimeFrameworkVersion>6.0.9</RuntimeFrameworkVersion>
+  </PropertyGroup>
+</Project>`)
+			})
+
+			it("ignores it", func() {
+				version, _, err := parser.ParseVersion(workingDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(Equal("6.0.0"))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when a Directory.Build.props file is malformed", func() {
+				it.Before(func() {
+					write(filepath.Join(workingDir, "Directory.Build.props"), "<Project>")
+				})
+
+				it("returns an error", func() {
+					_, _, err := parser.ParseVersion(workingDir, workingDir)
+					Expect(err).To(MatchError(ContainSubstring("failed to parse " + filepath.Join(workingDir, "Directory.Build.props"))))
+				})
+			})
+
+			context("when the project file cannot be read", func() {
+				it.Before(func() {
+					Expect(os.Chmod(filepath.Join(workingDir, "app.csproj"), 0000)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, _, err := parser.ParseVersion(workingDir, workingDir)
+					Expect(err).To(MatchError(ContainSubstring("permission denied")))
+				})
+			})
+		})
+	})
+}
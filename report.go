@@ -0,0 +1,158 @@
+package dotnetcoreruntime
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+// RuntimeReport is a machine-readable summary of the .NET Core Runtime that
+// Build resolved and, unless running as a dry run, installed for an
+// application.
+type RuntimeReport struct {
+	DryRun           bool                     `json:"dry-run"`
+	SelfContained    bool                     `json:"self-contained,omitempty"`
+	VersionSource    string                   `json:"version-source,omitempty"`
+	RequestedVersion string                   `json:"requested-version,omitempty"`
+	Candidates       []RuntimeReportCandidate `json:"candidates,omitempty"`
+	Dependency       RuntimeReportDependency  `json:"dependency"`
+	RollForward      []string                 `json:"roll-forward,omitempty"`
+	CacheReused      bool                     `json:"cache-reused"`
+	TrimmedFiles     []string                 `json:"trimmed-files,omitempty"`
+	InstallDuration  int64                    `json:"install-duration-ms"`
+	SBOMDuration     int64                    `json:"sbom-duration-ms"`
+	Launch           bool                     `json:"launch"`
+	Build            bool                     `json:"build"`
+	LaunchEnv        packit.Environment       `json:"launch-env,omitempty"`
+	BuildEnv         packit.Environment       `json:"build-env,omitempty"`
+}
+
+type RuntimeReportCandidate struct {
+	VersionSource string `json:"version-source,omitempty"`
+	Version       string `json:"version,omitempty"`
+	Launch        bool   `json:"launch"`
+	Build         bool   `json:"build"`
+}
+
+type RuntimeReportDependency struct {
+	ID              string     `json:"id"`
+	Name            string     `json:"name,omitempty"`
+	Version         string     `json:"version"`
+	URI             string     `json:"uri,omitempty"`
+	SHA256          string     `json:"sha256,omitempty"`
+	DeprecationDate *time.Time `json:"deprecation-date,omitempty"`
+	Deprecated      bool       `json:"deprecated"`
+}
+
+func newRuntimeReportCandidates(entries []packit.BuildpackPlanEntry) []RuntimeReportCandidate {
+	var candidates []RuntimeReportCandidate
+	for _, entry := range entries {
+		var candidate RuntimeReportCandidate
+		candidate.VersionSource, _ = entry.Metadata["version-source"].(string)
+		candidate.Version, _ = entry.Metadata["version"].(string)
+		candidate.Launch, _ = entry.Metadata["launch"].(bool)
+		candidate.Build, _ = entry.Metadata["build"].(bool)
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+func newRuntimeReportDependency(dependency postal.Dependency, now time.Time) RuntimeReportDependency {
+	reportDependency := RuntimeReportDependency{
+		ID:      dependency.ID,
+		Name:    dependency.Name,
+		Version: dependency.Version,
+		URI:     dependency.URI,
+		SHA256:  dependency.SHA256, //nolint:staticcheck
+	}
+
+	if !dependency.DeprecationDate.IsZero() {
+		deprecationDate := dependency.DeprecationDate
+		reportDependency.DeprecationDate = &deprecationDate
+		reportDependency.Deprecated = !deprecationDate.After(now)
+	}
+
+	return reportDependency
+}
+
+// prepareReport returns the path the runtime report should be written to. The
+// path can be given through $BP_DOTNET_RUNTIME_REPORT_PATH, relative to the
+// working directory, in which case the report becomes part of the application
+// image and emitReport warns about it; otherwise the report is written into a dedicated build
+// layer, which is returned so that it can be included in the build result.
+// The layer is not exported, since the timings in the report would give the
+// image a new layer on every rebuild.
+func prepareReport(context packit.BuildContext) (string, []packit.Layer, error) {
+	if path, ok := os.LookupEnv("BP_DOTNET_RUNTIME_REPORT_PATH"); ok && path != "" {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(context.WorkingDir, path)
+		}
+		return path, nil, nil
+	}
+
+	reportLayer, err := context.Layers.Get("dotnet-core-runtime-report")
+	if err != nil {
+		return "", nil, err
+	}
+
+	reportLayer, err = reportLayer.Reset()
+	if err != nil {
+		return "", nil, err
+	}
+
+	reportLayer.Build = true
+
+	return filepath.Join(reportLayer.Path, "report.json"), []packit.Layer{reportLayer}, nil
+}
+
+// emitReport writes the runtime report and returns any layers that must be
+// included in the build result to persist it.
+func emitReport(context packit.BuildContext, report RuntimeReport, logger scribe.Emitter) ([]packit.Layer, error) {
+	path, layers, err := prepareReport(context)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeReport(path, report)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Process("Wrote runtime report to %s", path)
+	if insideDir(context.WorkingDir, path) {
+		logger.Subprocess("WARNING: the runtime report is written inside the application directory, so the image changes on every rebuild")
+	}
+	logger.Break()
+
+	return layers, nil
+}
+
+// insideDir reports whether path is dir itself or lies beneath it.
+func insideDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func writeReport(path string, report RuntimeReport) error {
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(content, '\n'), 0644)
+}
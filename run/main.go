@@ -21,14 +21,19 @@ func (f Generator) GenerateFromDependency(dependency postal.Dependency, path str
 
 func main() {
 	bpYMLParser := dotnetcoreruntime.NewBuildpackYMLParser()
+	versionFileParser := dotnetcoreruntime.NewVersionFileParser()
+	globalJSONParser := dotnetcoreruntime.NewGlobalJSONParser()
+	depsJSONParser := dotnetcoreruntime.NewDepsJSONParser()
+	projectParser := dotnetcoreruntime.NewProjectFileParser()
 	logEmitter := scribe.NewEmitter(os.Stdout).WithLevel(os.Getenv("BP_LOG_LEVEL"))
+	toolVersionsParser := dotnetcoreruntime.NewToolVersionsParser(logEmitter)
 	entryResolver := draft.NewPlanner()
-	dependencyManager := postal.NewService(cargo.NewTransport())
+	dependencyManager := dotnetcoreruntime.NewFallbackDependencyManager(dotnetcoreruntime.NewDownloadCache(postal.NewService(cargo.NewTransport()), cargo.NewTransport(), logEmitter, chronos.DefaultClock), logEmitter)
 	symlinker := dotnetcoreruntime.NewSymlinker()
 	runtimeVersionResolver := dotnetcoreruntime.NewRuntimeVersionResolver(logEmitter)
 
 	packit.Run(
-		dotnetcoreruntime.Detect(bpYMLParser),
+		dotnetcoreruntime.Detect(bpYMLParser, versionFileParser, toolVersionsParser, globalJSONParser, depsJSONParser, projectParser, logEmitter),
 		dotnetcoreruntime.Build(
 			entryResolver,
 			dependencyManager,
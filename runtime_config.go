@@ -0,0 +1,220 @@
+package dotnetcoreruntime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+// RuntimeConfigMismatch describes a framework reference in a
+// *.runtimeconfig.json file that the installed runtime cannot satisfy, or a
+// file whose framework references could not be checked.
+type RuntimeConfigMismatch struct {
+	Path             string
+	RequiredVersion  string
+	RollForward      string
+	InstalledVersion string
+
+	// Err is set when the file is malformed or one of its framework
+	// references cannot be understood.
+	Err error
+}
+
+func (m RuntimeConfigMismatch) String() string {
+	if m.Err != nil {
+		return fmt.Sprintf("%s cannot be checked against .NET Core Runtime %s: %s", filepath.Base(m.Path), m.InstalledVersion, m.Err)
+	}
+
+	return fmt.Sprintf("%s requires Microsoft.NETCore.App %s (rollForward: %s), which .NET Core Runtime %s does not satisfy",
+		filepath.Base(m.Path), m.RequiredVersion, m.RollForward, m.InstalledVersion)
+}
+
+// CheckRuntimeConfigs compares the Microsoft.NETCore.App framework reference
+// of every *.runtimeconfig.json file at the root of the project directory
+// against the installed runtime version, following the roll-forward rules of
+// the .NET host, and returns the references that cannot be satisfied. Files
+// that are malformed, and references with an unparsable version or an unknown
+// rollForward policy, are returned as mismatches with Err set, so that the
+// caller decides whether they fail the build. Self-contained applications,
+// which carry their own runtime, are ignored.
+func CheckRuntimeConfigs(projectDir, installedVersion string) ([]RuntimeConfigMismatch, error) {
+	paths, err := filepath.Glob(filepath.Join(projectDir, "*.runtimeconfig.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	installed, err := semver.NewVersion(installedVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse installed runtime version %q: %w", installedVersion, err)
+	}
+
+	var mismatches []RuntimeConfigMismatch
+	for _, path := range paths {
+		config, err := readRuntimeConfig(path)
+		if err != nil {
+			mismatches = append(mismatches, RuntimeConfigMismatch{
+				Path:             path,
+				InstalledVersion: installedVersion,
+				Err:              err,
+			})
+			continue
+		}
+
+		for _, framework := range config.frameworks() {
+			if framework.Name != "Microsoft.NETCore.App" {
+				continue
+			}
+
+			rollForward := framework.RollForward
+			if rollForward == "" {
+				rollForward = config.RuntimeOptions.RollForward
+			}
+			if rollForward == "" {
+				rollForward = "Minor"
+			}
+
+			mismatch := RuntimeConfigMismatch{
+				Path:             path,
+				RequiredVersion:  framework.Version,
+				RollForward:      rollForward,
+				InstalledVersion: installedVersion,
+			}
+
+			required, err := semver.NewVersion(framework.Version)
+			if err != nil {
+				mismatch.Err = fmt.Errorf("failed to parse framework version %q: %w", framework.Version, err)
+				mismatches = append(mismatches, mismatch)
+				continue
+			}
+
+			satisfied, err := rollForwardSatisfied(required, installed, rollForward)
+			if err != nil {
+				mismatch.Err = err
+				mismatches = append(mismatches, mismatch)
+				continue
+			}
+
+			if !satisfied {
+				mismatches = append(mismatches, mismatch)
+			}
+		}
+	}
+
+	return mismatches, nil
+}
+
+// IsSelfContained reports whether the project directory holds a
+// self-contained publish output, which carries its own runtime: either a
+// *.runtimeconfig.json file at its root lists includedFrameworks, or the
+// apphost of one of them sits next to libcoreclr.so. Files that cannot be
+// read or parsed are skipped here; CheckRuntimeConfigs reports them.
+func IsSelfContained(projectDir string) (bool, error) {
+	paths, err := filepath.Glob(filepath.Join(projectDir, "*.runtimeconfig.json"))
+	if err != nil {
+		return false, err
+	}
+	sort.Strings(paths)
+
+	_, err = os.Stat(filepath.Join(projectDir, "libcoreclr.so"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+	hasCoreCLR := err == nil
+
+	for _, path := range paths {
+		config, err := readRuntimeConfig(path)
+		if err != nil {
+			continue
+		}
+
+		if len(config.RuntimeOptions.IncludedFrameworks) > 0 {
+			return true, nil
+		}
+
+		if hasCoreCLR {
+			info, err := os.Stat(strings.TrimSuffix(path, ".runtimeconfig.json"))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return false, err
+			}
+
+			if err == nil && info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0 {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+type runtimeConfig struct {
+	RuntimeOptions struct {
+		RollForward        string                   `json:"rollForward"`
+		Framework          *runtimeConfigFramework  `json:"framework"`
+		Frameworks         []runtimeConfigFramework `json:"frameworks"`
+		IncludedFrameworks []runtimeConfigFramework `json:"includedFrameworks"`
+	} `json:"runtimeOptions"`
+}
+
+// frameworks returns the shared frameworks that the application references,
+// whether through framework or frameworks.
+func (c runtimeConfig) frameworks() []runtimeConfigFramework {
+	frameworks := c.RuntimeOptions.Frameworks
+	if c.RuntimeOptions.Framework != nil {
+		frameworks = append(frameworks, *c.RuntimeOptions.Framework)
+	}
+	return frameworks
+}
+
+func readRuntimeConfig(path string) (runtimeConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return runtimeConfig{}, err
+	}
+
+	var config runtimeConfig
+	err = json.Unmarshal(content, &config)
+	if err != nil {
+		return runtimeConfig{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+type runtimeConfigFramework struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	RollForward string `json:"rollForward"`
+}
+
+// rollForwardSatisfied reports whether the .NET host would run an application
+// that requires the given framework version on the installed version under
+// the given roll-forward policy.
+func rollForwardSatisfied(required, installed *semver.Version, rollForward string) (bool, error) {
+	if installed.LessThan(required) {
+		return false, nil
+	}
+
+	switch strings.ToLower(rollForward) {
+	case "disable":
+		return installed.Equal(required), nil
+	case "latestpatch":
+		return installed.Major() == required.Major() && installed.Minor() == required.Minor(), nil
+	case "minor", "latestminor":
+		return installed.Major() == required.Major(), nil
+	case "major", "latestmajor":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown rollForward value %q", rollForward)
+	}
+}
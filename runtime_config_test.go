@@ -0,0 +1,243 @@
+package dotnetcoreruntime_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	dotnetcoreruntime "github.com/paketo-buildpacks/dotnet-core-runtime"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testRuntimeConfig(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		workingDir string
+	)
+
+	it.Before(func() {
+		var err error
+		workingDir, err = os.MkdirTemp("", "working-dir")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	writeRuntimeConfig := func(name, runtimeOptions string) {
+		Expect(os.WriteFile(filepath.Join(workingDir, name), []byte(fmt.Sprintf(`{"runtimeOptions": %s}`, runtimeOptions)), 0600)).To(Succeed())
+	}
+
+	context("CheckRuntimeConfigs", func() {
+		context("when there are no runtimeconfig.json files", func() {
+			it("returns no mismatches", func() {
+				mismatches, err := dotnetcoreruntime.CheckRuntimeConfigs(workingDir, "6.0.13")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mismatches).To(BeEmpty())
+			})
+		})
+
+		context("when the framework can be rolled forward to the installed version", func() {
+			it.Before(func() {
+				writeRuntimeConfig("app.runtimeconfig.json", `{"framework": {"name": "Microsoft.NETCore.App", "version": "6.0.0"}}`)
+			})
+
+			it("returns no mismatches", func() {
+				mismatches, err := dotnetcoreruntime.CheckRuntimeConfigs(workingDir, "6.0.13")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mismatches).To(BeEmpty())
+			})
+		})
+
+		context("when the framework requires a newer version", func() {
+			it.Before(func() {
+				writeRuntimeConfig("app.runtimeconfig.json", `{"framework": {"name": "Microsoft.NETCore.App", "version": "6.0.14"}}`)
+				writeRuntimeConfig("worker.runtimeconfig.json", `{"frameworks": [{"name": "Microsoft.AspNetCore.App", "version": "9.0.0"}, {"name": "Microsoft.NETCore.App", "version": "7.0.0"}]}`)
+			})
+
+			it("returns a mismatch for every file", func() {
+				mismatches, err := dotnetcoreruntime.CheckRuntimeConfigs(workingDir, "6.0.13")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mismatches).To(Equal([]dotnetcoreruntime.RuntimeConfigMismatch{
+					{
+						Path:             filepath.Join(workingDir, "app.runtimeconfig.json"),
+						RequiredVersion:  "6.0.14",
+						RollForward:      "Minor",
+						InstalledVersion: "6.0.13",
+					},
+					{
+						Path:             filepath.Join(workingDir, "worker.runtimeconfig.json"),
+						RequiredVersion:  "7.0.0",
+						RollForward:      "Minor",
+						InstalledVersion: "6.0.13",
+					},
+				}))
+				Expect(mismatches[0].String()).To(Equal("app.runtimeconfig.json requires Microsoft.NETCore.App 6.0.14 (rollForward: Minor), which .NET Core Runtime 6.0.13 does not satisfy"))
+			})
+		})
+
+		context("when the application is self-contained", func() {
+			it.Before(func() {
+				writeRuntimeConfig("app.runtimeconfig.json", `{"includedFrameworks": [{"name": "Microsoft.NETCore.App", "version": "8.0.0"}]}`)
+			})
+
+			it("returns no mismatches", func() {
+				mismatches, err := dotnetcoreruntime.CheckRuntimeConfigs(workingDir, "6.0.13")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mismatches).To(BeEmpty())
+			})
+		})
+
+		context("roll-forward policies", func() {
+			for _, c := range []struct {
+				rollForward string
+				required    string
+				satisfied   bool
+			}{
+				{"Disable", "6.0.13", true},
+				{"Disable", "6.0.12", false},
+				{"LatestPatch", "6.0.1", true},
+				{"LatestPatch", "6.1.0", false},
+				{"LatestPatch", "5.0.0", false},
+				{"Minor", "6.0.0", true},
+				{"LatestMinor", "5.0.0", false},
+				{"Major", "5.0.0", true},
+				{"LatestMajor", "3.1.0", true},
+				{"Major", "7.0.0", false},
+			} {
+				c := c
+				it(fmt.Sprintf("applies %s to %s", c.rollForward, c.required), func() {
+					writeRuntimeConfig("app.runtimeconfig.json", fmt.Sprintf(`{"rollForward": %q, "framework": {"name": "Microsoft.NETCore.App", "version": %q}}`, c.rollForward, c.required))
+
+					mismatches, err := dotnetcoreruntime.CheckRuntimeConfigs(workingDir, "6.0.13")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(len(mismatches) == 0).To(Equal(c.satisfied))
+				})
+			}
+		})
+
+		context("when the framework reference sets its own rollForward", func() {
+			it.Before(func() {
+				writeRuntimeConfig("app.runtimeconfig.json", `{"rollForward": "Major", "framework": {"name": "Microsoft.NETCore.App", "version": "5.0.0", "rollForward": "Minor"}}`)
+			})
+
+			it("uses the framework's policy", func() {
+				mismatches, err := dotnetcoreruntime.CheckRuntimeConfigs(workingDir, "6.0.13")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mismatches).To(HaveLen(1))
+				Expect(mismatches[0].RollForward).To(Equal("Minor"))
+			})
+		})
+
+		context("when a runtimeconfig.json is malformed", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "app.runtimeconfig.json"), []byte("%%%"), 0600)).To(Succeed())
+			})
+
+			it("returns a mismatch that records the problem", func() {
+				mismatches, err := dotnetcoreruntime.CheckRuntimeConfigs(workingDir, "6.0.13")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mismatches).To(HaveLen(1))
+				Expect(mismatches[0].Err).To(MatchError(ContainSubstring("failed to parse")))
+				Expect(mismatches[0].String()).To(HavePrefix("app.runtimeconfig.json cannot be checked against .NET Core Runtime 6.0.13: failed to parse"))
+			})
+		})
+
+		context("when the rollForward value is unknown", func() {
+			it.Before(func() {
+				writeRuntimeConfig("app.runtimeconfig.json", `{"rollForward": "Sideways", "framework": {"name": "Microsoft.NETCore.App", "version": "6.0.0"}}`)
+			})
+
+			it("returns a mismatch that records the problem", func() {
+				mismatches, err := dotnetcoreruntime.CheckRuntimeConfigs(workingDir, "6.0.13")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mismatches).To(HaveLen(1))
+				Expect(mismatches[0].Err).To(MatchError(`unknown rollForward value "Sideways"`))
+			})
+		})
+
+		context("when the framework version is not a semantic version", func() {
+			it.Before(func() {
+				writeRuntimeConfig("app.runtimeconfig.json", `{"framework": {"name": "Microsoft.NETCore.App", "version": "six"}}`)
+			})
+
+			it("returns a mismatch that records the problem", func() {
+				mismatches, err := dotnetcoreruntime.CheckRuntimeConfigs(workingDir, "6.0.13")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mismatches).To(HaveLen(1))
+				Expect(mismatches[0].Err).To(MatchError(ContainSubstring(`failed to parse framework version "six"`)))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the installed version is not a semantic version", func() {
+				it.Before(func() {
+					writeRuntimeConfig("app.runtimeconfig.json", `{"framework": {"name": "Microsoft.NETCore.App", "version": "6.0.0"}}`)
+				})
+
+				it("returns an error", func() {
+					_, err := dotnetcoreruntime.CheckRuntimeConfigs(workingDir, "not-a-version")
+					Expect(err).To(MatchError(ContainSubstring(`failed to parse installed runtime version "not-a-version"`)))
+				})
+			})
+		})
+	})
+
+	context("IsSelfContained", func() {
+		it("returns false for a framework-dependent application", func() {
+			writeRuntimeConfig("app.runtimeconfig.json", `{"framework": {"name": "Microsoft.NETCore.App", "version": "6.0.0"}}`)
+
+			selfContained, err := dotnetcoreruntime.IsSelfContained(workingDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(selfContained).To(BeFalse())
+		})
+
+		it("returns true when a runtimeconfig.json lists includedFrameworks", func() {
+			writeRuntimeConfig("app.runtimeconfig.json", `{"includedFrameworks": [{"name": "Microsoft.NETCore.App", "version": "6.0.13"}]}`)
+
+			selfContained, err := dotnetcoreruntime.IsSelfContained(workingDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(selfContained).To(BeTrue())
+		})
+
+		context("when libcoreclr.so is in the application directory", func() {
+			it.Before(func() {
+				writeRuntimeConfig("app.runtimeconfig.json", `{}`)
+				Expect(os.WriteFile(filepath.Join(workingDir, "libcoreclr.so"), nil, 0600)).To(Succeed())
+			})
+
+			it("returns true when the apphost is next to it", func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "app"), nil, 0700)).To(Succeed())
+
+				selfContained, err := dotnetcoreruntime.IsSelfContained(workingDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(selfContained).To(BeTrue())
+			})
+
+			it("returns false when there is no executable apphost", func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "app"), nil, 0600)).To(Succeed())
+
+				selfContained, err := dotnetcoreruntime.IsSelfContained(workingDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(selfContained).To(BeFalse())
+			})
+		})
+
+		context("when a runtimeconfig.json is malformed", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "app.runtimeconfig.json"), []byte("%%%"), 0600)).To(Succeed())
+			})
+
+			it("skips it", func() {
+				selfContained, err := dotnetcoreruntime.IsSelfContained(workingDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(selfContained).To(BeFalse())
+			})
+		})
+	})
+}
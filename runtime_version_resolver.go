@@ -7,7 +7,6 @@ import (
 	"sort"
 	"strings"
 
-	"github.com/BurntSushi/toml"
 	"github.com/Masterminds/semver"
 	"github.com/paketo-buildpacks/packit/v2"
 	"github.com/paketo-buildpacks/packit/v2/postal"
@@ -22,8 +21,8 @@ func NewRuntimeVersionResolver(logger scribe.Emitter) RuntimeVersionResolver {
 	return RuntimeVersionResolver{logger: logger}
 }
 
-func (r RuntimeVersionResolver) Resolve(path string, entry packit.BuildpackPlanEntry, stack string) (postal.Dependency, error) {
-	dotnetRuntimeDependencies, defaultVersion, err := filterBuildpackTOML(path, entry.Name, stack)
+func (r RuntimeVersionResolver) Resolve(path string, entry packit.BuildpackPlanEntry, stack, platformDir string) (postal.Dependency, error) {
+	dotnetRuntimeDependencies, defaultVersion, err := r.filterBuildpackTOML(path, platformDir, entry.Name, stack)
 	if err != nil {
 		return postal.Dependency{}, err
 	}
@@ -126,37 +125,88 @@ func containsStack(stacks []string, stack string) bool {
 }
 
 func gatherVersionConstraints(version string, versionSource string, allowRollForward bool) ([]semver.Constraints, error) {
+	expressions, err := versionConstraints(version, versionSource, allowRollForward)
+	if err != nil {
+		return nil, err
+	}
+
 	var constraints []semver.Constraints
-	runtimeConstraint, err := semver.NewConstraint(version)
+	for _, expression := range expressions {
+		constraint, err := semver.NewConstraint(expression)
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, *constraint)
+	}
+	return constraints, nil
+}
+
+// explicitVersionSources are the version sources whose version is used as is,
+// without rolling forward to a newer minor or major version.
+var explicitVersionSources = map[string]bool{
+	"BP_DOTNET_FRAMEWORK_VERSION": true,
+	".dotnet-version":             true,
+	".tool-versions":              true,
+	"buildpack.yml":               true,
+	"global.json":                 true,
+}
+
+// versionConstraints returns the constraint expressions that are tried, in
+// order, to find a dependency for the requested version.
+func versionConstraints(version string, versionSource string, allowRollForward bool) ([]string, error) {
+	_, err := semver.NewConstraint(version)
 	if err != nil {
 		return nil, err
 	}
-	constraints = append(constraints, *runtimeConstraint)
+	expressions := []string{version}
 
-	// Don't add roll forward constraints if the version source is BP_DOTNET_FRAMEWORK_VERSION or buildpack.yml
+	// Don't add roll forward constraints if the version source is set explicitly (see explicitVersionSources)
 	// Don't add roll forward constraints if roll forward is not allowed (via `BP_DOTNET_ROLL_FORWARD=Disable`)
-	if versionSource != "BP_DOTNET_FRAMEWORK_VERSION" && versionSource != "buildpack.yml" && allowRollForward {
+	if !explicitVersionSources[versionSource] && allowRollForward {
 		// If version is 1.2.3 or 1.2.* but not 1.2 or 1.*
 		if match, _ := regexp.MatchString(`\d+\.\d+\.(\d+$|\*$)`, version); match {
 			runtimeVersion, err := semver.NewVersion(strings.TrimSuffix(version, `.*`))
 			if err != nil {
-				return []semver.Constraints{}, err
+				return nil, err
 			}
 
-			minorConstraint, err := semver.NewConstraint(fmt.Sprintf("%d.%d.*", runtimeVersion.Major(), runtimeVersion.Minor()))
-			if err != nil {
-				return []semver.Constraints{}, err
-			}
-			constraints = append(constraints, *minorConstraint)
+			expressions = append(expressions,
+				fmt.Sprintf("%d.%d.*", runtimeVersion.Major(), runtimeVersion.Minor()),
+				fmt.Sprintf("%d.*", runtimeVersion.Major()),
+			)
+		}
+	}
+	return expressions, nil
+}
 
-			majorConstraint, err := semver.NewConstraint(fmt.Sprintf("%d.*", runtimeVersion.Major()))
-			if err != nil {
-				return []semver.Constraints{}, err
-			}
-			constraints = append(constraints, *majorConstraint)
+// rollForwardSteps returns the version constraints that were evaluated to
+// select a dependency, up to and including the first one that the selected
+// version satisfies.
+func rollForwardSteps(version, versionSource, selected string) []string {
+	if version == "" || version == "default" {
+		return nil
+	}
+
+	selectedVersion, err := semver.NewVersion(selected)
+	if err != nil {
+		return nil
+	}
+
+	expressions, err := versionConstraints(version, versionSource, allowRollForward())
+	if err != nil {
+		return nil
+	}
+
+	var steps []string
+	for _, expression := range expressions {
+		steps = append(steps, expression)
+
+		constraint, err := semver.NewConstraint(expression)
+		if err == nil && constraint.Check(selectedVersion) {
+			break
 		}
 	}
-	return constraints, nil
+	return steps
 }
 
 func allowRollForward() bool {
@@ -168,24 +218,24 @@ func allowRollForward() bool {
 	return true
 }
 
-func filterBuildpackTOML(path, dependencyID, stack string) ([]postal.Dependency, string, error) {
-	var buildpackTOML struct {
-		Metadata struct {
-			DefaultVersions map[string]string   `toml:"default-versions"`
-			Dependencies    []postal.Dependency `toml:"dependencies"`
-		} `toml:"metadata"`
-	}
-
-	_, err := toml.DecodeFile(path, &buildpackTOML)
+func (r RuntimeVersionResolver) filterBuildpackTOML(path, platformDir, dependencyID, stack string) ([]postal.Dependency, string, error) {
+	metadata, overlays, err := LoadDependencyCatalog(path, platformDir)
 	if err != nil {
 		return []postal.Dependency{}, "", err
 	}
 
+	for _, overlay := range overlays {
+		r.logger.Subprocess("Using dependency catalog from %s (%s mode)", overlay.Source, overlay.Mode)
+	}
+	if len(overlays) > 0 {
+		r.logger.Break()
+	}
+
 	var filteredDependencies []postal.Dependency
-	for _, dependency := range buildpackTOML.Metadata.Dependencies {
+	for _, dependency := range metadata.Dependencies {
 		if dependency.ID == dependencyID && containsStack(dependency.Stacks, stack) {
 			filteredDependencies = append(filteredDependencies, dependency)
 		}
 	}
-	return filteredDependencies, buildpackTOML.Metadata.DefaultVersions[dependencyID], nil
+	return filteredDependencies, metadata.DefaultVersions[dependencyID], nil
 }
@@ -2,6 +2,7 @@ package dotnetcoreruntime_test
 
 import (
 	"bytes"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -93,7 +94,7 @@ func testRuntimeVersionResolver(t *testing.T, context spec.G, it spec.S) {
 		})
 
 		it("returns the default version", func() {
-			dependency, err := versionResolver.Resolve(buildpackToml, entry, "some-stack")
+			dependency, err := versionResolver.Resolve(buildpackToml, entry, "some-stack", "")
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(dependency).To(Equal(postal.Dependency{
@@ -116,7 +117,7 @@ func testRuntimeVersionResolver(t *testing.T, context spec.G, it spec.S) {
 				entry.Metadata["version"] = "2.2.3"
 			})
 			it("returns a dependency with that version", func() {
-				dependency, err := versionResolver.Resolve(buildpackToml, entry, "some-stack")
+				dependency, err := versionResolver.Resolve(buildpackToml, entry, "some-stack", "")
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(dependency).To(Equal(postal.Dependency{
@@ -134,7 +135,7 @@ func testRuntimeVersionResolver(t *testing.T, context spec.G, it spec.S) {
 				entry.Metadata["version"] = "2.2.0"
 			})
 			it("returns a compatible version", func() {
-				dependency, err := versionResolver.Resolve(buildpackToml, entry, "some-stack")
+				dependency, err := versionResolver.Resolve(buildpackToml, entry, "some-stack", "")
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(dependency).To(Equal(postal.Dependency{
@@ -156,7 +157,7 @@ func testRuntimeVersionResolver(t *testing.T, context spec.G, it spec.S) {
 				Expect(os.Unsetenv("BP_DOTNET_ROLL_FORWARD")).To(Succeed())
 			})
 			it("returns a compatible version", func() {
-				_, err := versionResolver.Resolve(buildpackToml, entry, "some-stack")
+				_, err := versionResolver.Resolve(buildpackToml, entry, "some-stack", "")
 				Expect(err).To(MatchError(ContainSubstring(`failed to satisfy "dotnet-runtime" dependency for stack "some-stack" with version constraint "2.2.0": no compatible versions. Supported versions are: [1.2.2, 2.2.3, 2.2.4]. This may be due to BP_DOTNET_ROLL_FORWARD=Disable`)))
 			})
 		})
@@ -166,7 +167,7 @@ func testRuntimeVersionResolver(t *testing.T, context spec.G, it spec.S) {
 				entry.Metadata["version"] = "2.1.7"
 			})
 			it("returns a compatible version", func() {
-				dependency, err := versionResolver.Resolve(buildpackToml, entry, "some-stack")
+				dependency, err := versionResolver.Resolve(buildpackToml, entry, "some-stack", "")
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(dependency).To(Equal(postal.Dependency{
@@ -185,7 +186,7 @@ func testRuntimeVersionResolver(t *testing.T, context spec.G, it spec.S) {
 					entry.Metadata["version"] = "3.0.0"
 				})
 				it("returns an error", func() {
-					_, err := versionResolver.Resolve(buildpackToml, entry, "some-stack")
+					_, err := versionResolver.Resolve(buildpackToml, entry, "some-stack", "")
 					Expect(err).To(MatchError(ContainSubstring(`failed to satisfy "dotnet-runtime" dependency for stack "some-stack" with version constraint "3.0.0": no compatible versions. Supported versions are: [1.2.2, 2.2.3, 2.2.4]`)))
 					Expect(err).NotTo(MatchError(ContainSubstring(`. This may be due to BP_DOTNET_ROLL_FORWARD=Disable`)))
 				})
@@ -196,7 +197,7 @@ func testRuntimeVersionResolver(t *testing.T, context spec.G, it spec.S) {
 					entry.Metadata["version"] = "2.3.0"
 				})
 				it("returns an error", func() {
-					_, err := versionResolver.Resolve(buildpackToml, entry, "some-stack")
+					_, err := versionResolver.Resolve(buildpackToml, entry, "some-stack", "")
 					Expect(err).To(MatchError(ContainSubstring(`failed to satisfy "dotnet-runtime" dependency for stack "some-stack" with version constraint "2.3.0": no compatible versions. Supported versions are: [1.2.2, 2.2.3, 2.2.4]`)))
 				})
 			})
@@ -206,7 +207,7 @@ func testRuntimeVersionResolver(t *testing.T, context spec.G, it spec.S) {
 					entry.Metadata["version"] = "2.2.5"
 				})
 				it("returns an error", func() {
-					_, err := versionResolver.Resolve(buildpackToml, entry, "some-stack")
+					_, err := versionResolver.Resolve(buildpackToml, entry, "some-stack", "")
 					Expect(err).To(MatchError(ContainSubstring(`failed to satisfy "dotnet-runtime" dependency for stack "some-stack" with version constraint "2.2.5": no compatible versions. Supported versions are: [1.2.2, 2.2.3, 2.2.4]`)))
 				})
 			})
@@ -218,7 +219,7 @@ func testRuntimeVersionResolver(t *testing.T, context spec.G, it spec.S) {
 				entry.Name = "random-ID"
 			})
 			it("returns an error", func() {
-				_, err := versionResolver.Resolve(buildpackToml, entry, "some-stack")
+				_, err := versionResolver.Resolve(buildpackToml, entry, "some-stack", "")
 				Expect(err).To(MatchError(ContainSubstring(`failed to satisfy "random-ID" dependency for stack "some-stack" with version constraint "2.2.3": no compatible versions. Supported versions are: []`)))
 			})
 		})
@@ -228,7 +229,7 @@ func testRuntimeVersionResolver(t *testing.T, context spec.G, it spec.S) {
 				entry.Metadata["version"] = "2.2.3"
 			})
 			it("returns an error", func() {
-				_, err := versionResolver.Resolve(buildpackToml, entry, "random-stack")
+				_, err := versionResolver.Resolve(buildpackToml, entry, "random-stack", "")
 				Expect(err).To(MatchError(ContainSubstring(`failed to satisfy "dotnet-runtime" dependency for stack "random-stack" with version constraint "2.2.3": no compatible versions. Supported versions are: []`)))
 			})
 		})
@@ -238,7 +239,7 @@ func testRuntimeVersionResolver(t *testing.T, context spec.G, it spec.S) {
 				entry.Metadata["version"] = "2.1.*"
 			})
 			it("allows patch and minor version rollforward", func() {
-				dependency, err := versionResolver.Resolve(buildpackToml, entry, "some-stack")
+				dependency, err := versionResolver.Resolve(buildpackToml, entry, "some-stack", "")
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(dependency).To(Equal(postal.Dependency{
@@ -253,7 +254,7 @@ func testRuntimeVersionResolver(t *testing.T, context spec.G, it spec.S) {
 
 		context("the version is empty", func() {
 			it("returns the default version", func() {
-				dependency, err := versionResolver.Resolve(buildpackToml, entry, "some-stack")
+				dependency, err := versionResolver.Resolve(buildpackToml, entry, "some-stack", "")
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(dependency).To(Equal(postal.Dependency{
@@ -271,7 +272,7 @@ func testRuntimeVersionResolver(t *testing.T, context spec.G, it spec.S) {
 				entry.Metadata["version"] = "default"
 			})
 			it("returns the default version", func() {
-				dependency, err := versionResolver.Resolve(buildpackToml, entry, "some-stack")
+				dependency, err := versionResolver.Resolve(buildpackToml, entry, "some-stack", "")
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(dependency).To(Equal(postal.Dependency{
@@ -295,7 +296,7 @@ func testRuntimeVersionResolver(t *testing.T, context spec.G, it spec.S) {
 				entry.Metadata["version"] = "2.2.0"
 			})
 			it("returns an error", func() {
-				_, err := versionResolver.Resolve(buildpackToml, entry, "some-stack")
+				_, err := versionResolver.Resolve(buildpackToml, entry, "some-stack", "")
 				Expect(err).To(MatchError(ContainSubstring(`failed to satisfy "dotnet-runtime" dependency for stack "some-stack" with version constraint "2.2.0": no compatible versions. Supported versions are: [1.2.2, 2.2.3, 2.2.4]`)))
 			})
 		})
@@ -305,7 +306,7 @@ func testRuntimeVersionResolver(t *testing.T, context spec.G, it spec.S) {
 				entry.Metadata["version"] = "2.2.*"
 			})
 			it("attempts to turn the given versions into the only constraint", func() {
-				dependency, err := versionResolver.Resolve(buildpackToml, entry, "some-stack")
+				dependency, err := versionResolver.Resolve(buildpackToml, entry, "some-stack", "")
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(dependency).To(Equal(postal.Dependency{
@@ -319,6 +320,34 @@ func testRuntimeVersionResolver(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 
+	context("the version source is .tool-versions", func() {
+		it.Before(func() {
+			entry.Metadata["version-source"] = ".tool-versions"
+			entry.Metadata["version"] = "2.2.0"
+		})
+
+		it("does not roll forward and returns an error", func() {
+			_, err := versionResolver.Resolve(buildpackToml, entry, "some-stack", "")
+			Expect(err).To(MatchError(ContainSubstring(`failed to satisfy "dotnet-runtime" dependency for stack "some-stack" with version constraint "2.2.0": no compatible versions.`)))
+		})
+	})
+
+	context("the version source is global.json", func() {
+		it.Before(func() {
+			entry.Metadata["version-source"] = "global.json"
+		})
+
+		context("the buildpack.toml has no match for the runtime line", func() {
+			it.Before(func() {
+				entry.Metadata["version"] = "2.1.*"
+			})
+			it("does not roll forward and returns an error", func() {
+				_, err := versionResolver.Resolve(buildpackToml, entry, "some-stack", "")
+				Expect(err).To(MatchError(ContainSubstring(`failed to satisfy "dotnet-runtime" dependency for stack "some-stack" with version constraint "2.1.*": no compatible versions. Supported versions are: [1.2.2, 2.2.3, 2.2.4]`)))
+			})
+		})
+	})
+
 	context("the version source is BP_DOTNET_FRAMEWORK_VERSION", func() {
 		it.Before(func() {
 			entry.Metadata["version-source"] = "BP_DOTNET_FRAMEWORK_VERSION"
@@ -329,7 +358,7 @@ func testRuntimeVersionResolver(t *testing.T, context spec.G, it spec.S) {
 				entry.Metadata["version"] = "2.2.0"
 			})
 			it("returns an error", func() {
-				_, err := versionResolver.Resolve(buildpackToml, entry, "some-stack")
+				_, err := versionResolver.Resolve(buildpackToml, entry, "some-stack", "")
 				Expect(err).To(MatchError(ContainSubstring(`failed to satisfy "dotnet-runtime" dependency for stack "some-stack" with version constraint "2.2.0": no compatible versions. Supported versions are: [1.2.2, 2.2.3, 2.2.4]`)))
 			})
 		})
@@ -339,7 +368,7 @@ func testRuntimeVersionResolver(t *testing.T, context spec.G, it spec.S) {
 				entry.Metadata["version"] = "2.2.*"
 			})
 			it("attempts to turn the given versions into the only constraint", func() {
-				dependency, err := versionResolver.Resolve(buildpackToml, entry, "some-stack")
+				dependency, err := versionResolver.Resolve(buildpackToml, entry, "some-stack", "")
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(dependency).To(Equal(postal.Dependency{
@@ -353,6 +382,35 @@ func testRuntimeVersionResolver(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 
+	context("when BP_DOTNET_RUNTIME_CATALOG supplies a dependency catalog", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(cnbDir, "catalog.toml"), []byte(`
+[[dependencies]]
+  id = "dotnet-runtime"
+  sha256 = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+  stacks = ["some-stack"]
+  uri = "https://mirror.example.com/dotnet-runtime-2.2.5.tgz"
+  version = "2.2.5"
+`), 0600)).To(Succeed())
+			Expect(os.Setenv("BP_DOTNET_RUNTIME_CATALOG", filepath.Join(cnbDir, "catalog.toml"))).To(Succeed())
+
+			entry.Metadata["version"] = "2.2.*"
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_DOTNET_RUNTIME_CATALOG")).To(Succeed())
+		})
+
+		it("resolves against the overlaid dependencies", func() {
+			dependency, err := versionResolver.Resolve(buildpackToml, entry, "some-stack", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(dependency.Version).To(Equal("2.2.5"))
+			Expect(dependency.URI).To(Equal("https://mirror.example.com/dotnet-runtime-2.2.5.tgz"))
+			Expect(buffer.String()).To(ContainSubstring(fmt.Sprintf("Using dependency catalog from %s (merge mode)", filepath.Join(cnbDir, "catalog.toml"))))
+		})
+	})
+
 	context("failure cases", func() {
 		context("the buildpack.toml cannot be parsed", func() {
 			it.Before(func() {
@@ -361,7 +419,7 @@ func testRuntimeVersionResolver(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it("returns an error", func() {
-				_, err := versionResolver.Resolve(buildpackToml, entry, "some-stack")
+				_, err := versionResolver.Resolve(buildpackToml, entry, "some-stack", "")
 				Expect(err).To(MatchError(ContainSubstring("expected '.' or '=', but got '%' instead")))
 			})
 		})
@@ -371,7 +429,7 @@ func testRuntimeVersionResolver(t *testing.T, context spec.G, it spec.S) {
 				entry.Metadata["version"] = "invalid-version"
 			})
 			it("returns an error", func() {
-				_, err := versionResolver.Resolve(buildpackToml, entry, "some-stack")
+				_, err := versionResolver.Resolve(buildpackToml, entry, "some-stack", "")
 				Expect(err).To(MatchError(ContainSubstring("improper constraint")))
 			})
 		})
@@ -405,7 +463,7 @@ version = "2.2.4"
 			})
 
 			it("returns an error", func() {
-				_, err := versionResolver.Resolve(buildpackToml, entry, "some-stack")
+				_, err := versionResolver.Resolve(buildpackToml, entry, "some-stack", "")
 				Expect(err).To(MatchError(ContainSubstring("Invalid Semantic Version")))
 			})
 		})
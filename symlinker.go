@@ -11,18 +11,20 @@ func NewSymlinker() Symlinker {
 	return Symlinker{}
 }
 
-func (s Symlinker) Link(workingDir, layerPath string) error {
-	err := os.MkdirAll(filepath.Join(workingDir, ".dotnet_root", "shared"), os.ModePerm)
+// Link builds a DOTNET_ROOT tree at dotnetRoot whose host and
+// Microsoft.NETCore.App framework entries point into the runtime layer.
+func (s Symlinker) Link(dotnetRoot, layerPath string) error {
+	err := os.MkdirAll(filepath.Join(dotnetRoot, "shared"), os.ModePerm)
 	if err != nil {
 		return err
 	}
 
-	err = os.Symlink(filepath.Join(layerPath, "shared", "Microsoft.NETCore.App"), filepath.Join(workingDir, ".dotnet_root", "shared", "Microsoft.NETCore.App"))
+	err = os.Symlink(filepath.Join(layerPath, "shared", "Microsoft.NETCore.App"), filepath.Join(dotnetRoot, "shared", "Microsoft.NETCore.App"))
 	if err != nil {
 		return err
 	}
 
-	err = os.Symlink(filepath.Join(layerPath, "host"), filepath.Join(workingDir, ".dotnet_root", "host"))
+	err = os.Symlink(filepath.Join(layerPath, "host"), filepath.Join(dotnetRoot, "host"))
 	if err != nil {
 		return err
 	}
@@ -37,8 +37,8 @@ func testSymlinker(t *testing.T, context spec.G, it spec.S) {
 	})
 
 	context("Link", func() {
-		it("creates a .dotnet_root dir in workspace with symlink to layerpath", func() {
-			err := symlinker.Link(workingDir, layerPath)
+		it("creates a dotnet root dir with symlinks to layerpath", func() {
+			err := symlinker.Link(filepath.Join(workingDir, ".dotnet_root"), layerPath)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(filepath.Join(workingDir, ".dotnet_root", "shared")).To(BeADirectory())
 
@@ -65,7 +65,7 @@ func testSymlinker(t *testing.T, context spec.G, it spec.S) {
 					Expect(os.Chmod(filepath.Join(workingDir), 0000)).To(Succeed())
 				})
 				it("errors", func() {
-					err := symlinker.Link(workingDir, layerPath)
+					err := symlinker.Link(filepath.Join(workingDir, ".dotnet_root"), layerPath)
 					Expect(err).To(HaveOccurred())
 					Expect(err).To(MatchError(ContainSubstring("permission denied")))
 				})
@@ -77,7 +77,7 @@ func testSymlinker(t *testing.T, context spec.G, it spec.S) {
 					Expect(os.Chmod(filepath.Join(workingDir, ".dotnet_root", "shared"), 0000)).To(Succeed())
 				})
 				it("errors", func() {
-					err := symlinker.Link(workingDir, layerPath)
+					err := symlinker.Link(filepath.Join(workingDir, ".dotnet_root"), layerPath)
 					Expect(err).To(HaveOccurred())
 					Expect(err).To(MatchError(ContainSubstring("permission denied")))
 				})
@@ -89,7 +89,7 @@ func testSymlinker(t *testing.T, context spec.G, it spec.S) {
 					Expect(os.Chmod(filepath.Join(workingDir, ".dotnet_root"), 0000)).To(Succeed())
 				})
 				it("errors", func() {
-					err := symlinker.Link(workingDir, layerPath)
+					err := symlinker.Link(filepath.Join(workingDir, ".dotnet_root"), layerPath)
 					Expect(err).To(HaveOccurred())
 					Expect(err).To(MatchError(ContainSubstring("permission denied")))
 				})
@@ -0,0 +1,103 @@
+package dotnetcoreruntime
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultTrimPatterns are the files removed from the runtime layer when
+// trimming is enabled and BP_DOTNET_RUNTIME_TRIM_PATTERNS is not set. They
+// are only needed to debug or trace the runtime.
+var DefaultTrimPatterns = []string{
+	"createdump",
+	"*.dbg",
+	"*.pdb",
+	"libmscordbi.so",
+	"libmscordaccore.so",
+	"libcoreclrtraceptprovider.so",
+}
+
+// trimConfiguration returns the patterns of the files to remove from the
+// runtime layer, or no patterns when BP_DOTNET_RUNTIME_TRIM is not enabled.
+func trimConfiguration() ([]string, error) {
+	enabled, err := parseBoolEnv("BP_DOTNET_RUNTIME_TRIM")
+	if err != nil {
+		return nil, err
+	}
+
+	if !enabled {
+		return nil, nil
+	}
+
+	patterns := DefaultTrimPatterns
+	if value, ok := os.LookupEnv("BP_DOTNET_RUNTIME_TRIM_PATTERNS"); ok && strings.TrimSpace(value) != "" {
+		patterns = nil
+		for _, pattern := range strings.Split(value, ",") {
+			pattern = strings.TrimSpace(pattern)
+			if pattern == "" {
+				continue
+			}
+
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid BP_DOTNET_RUNTIME_TRIM_PATTERNS pattern %q: %w", pattern, err)
+			}
+
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	return patterns, nil
+}
+
+// TrimRuntime removes the files in the layer that match any of the given
+// patterns and returns their paths relative to the layer, sorted. Patterns
+// that contain a slash are matched against the relative path of each file;
+// other patterns are matched against its name.
+func TrimRuntime(layerPath string, patterns []string) ([]string, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	var trimmed []string
+	err := filepath.WalkDir(layerPath, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(layerPath, filePath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		for _, pattern := range patterns {
+			subject := rel
+			if !strings.Contains(pattern, "/") {
+				subject = path.Base(rel)
+			}
+
+			if matched, _ := path.Match(pattern, subject); matched {
+				trimmed = append(trimmed, rel)
+				return os.Remove(filePath)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to trim runtime: %w", err)
+	}
+
+	sort.Strings(trimmed)
+
+	return trimmed, nil
+}
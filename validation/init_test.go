@@ -0,0 +1,14 @@
+package validation_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitValidation(t *testing.T) {
+	suite := spec.New("validation", spec.Report(report.Terminal{}))
+	suite("Validator", testValidator)
+	suite.Run(t)
+}
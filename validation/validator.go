@@ -0,0 +1,200 @@
+package validation
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	dotnetcoreruntime "github.com/paketo-buildpacks/dotnet-core-runtime"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+)
+
+// Violation describes a single inconsistency in the dependency metadata of a
+// buildpack.toml.
+type Violation struct {
+	Subject string
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Subject, v.Message)
+}
+
+// ValidateFile loads the buildpack.toml at the given path and returns every
+// violation found in its dependency metadata.
+func ValidateFile(path string) ([]Violation, error) {
+	buildpackTOML, err := dotnetcoreruntime.ParseBuildpackTOML(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Validate(buildpackTOML), nil
+}
+
+// Validate returns every violation found in the dependency metadata of the
+// given buildpack.toml.
+func Validate(buildpackTOML dotnetcoreruntime.BuildpackTOML) []Violation {
+	var violations []Violation
+
+	for _, dependency := range buildpackTOML.Metadata.Dependencies {
+		violations = append(violations, validateDependency(dependency)...)
+	}
+
+	violations = append(violations, validateDefaultVersions(buildpackTOML)...)
+	violations = append(violations, validateDependencyConstraints(buildpackTOML)...)
+
+	return violations
+}
+
+func validateDependency(dependency postal.Dependency) []Violation {
+	var violations []Violation
+	subject := fmt.Sprintf("dependency %s %s", dependency.ID, dependency.Version)
+	violate := func(format string, args ...interface{}) {
+		violations = append(violations, Violation{Subject: subject, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if _, err := semver.NewVersion(dependency.Version); err != nil {
+		violate("version %q is not a valid semantic version", dependency.Version)
+	}
+
+	sha := checksumHash(dependency.Checksum, dependency.SHA256)                   //nolint:staticcheck
+	sourceSHA := checksumHash(dependency.SourceChecksum, dependency.SourceSHA256) //nolint:staticcheck
+
+	if sha == "" {
+		violate("sha256 is missing")
+	}
+
+	if sourceSHA == "" {
+		violate("source_sha256 is missing")
+	}
+
+	if dependency.CPE != "" {
+		parts := strings.Split(dependency.CPE, ":")
+		if len(parts) < 6 || parts[5] != dependency.Version {
+			violate("cpe %q does not match version %q", dependency.CPE, dependency.Version)
+		}
+	}
+
+	if dependency.PURL != "" {
+		purlChecksum := ""
+		if _, query, found := strings.Cut(dependency.PURL, "?"); found {
+			values, err := url.ParseQuery(query)
+			if err != nil {
+				violate("purl %q cannot be parsed: %s", dependency.PURL, err)
+			}
+			purlChecksum = values.Get("checksum")
+		}
+
+		if purlChecksum != sourceSHA {
+			violate("purl checksum %q does not match source_sha256 %q", purlChecksum, sourceSHA)
+		}
+	}
+
+	// Dependencies that are repackaged by Paketo embed the first eight
+	// characters of their SHA256 in the artifact name, while those that point
+	// at the upstream artifact must carry the same checksum as their source.
+	if dependency.URI != dependency.Source {
+		if len(sha) >= 8 && !strings.Contains(path.Base(dependency.URI), sha[:8]) {
+			violate("uri %q does not embed the sha256 prefix %q", dependency.URI, sha[:8])
+		}
+	} else if sha != sourceSHA {
+		violate("sha256 %q does not match source_sha256 %q for an upstream uri", sha, sourceSHA)
+	}
+
+	return violations
+}
+
+func validateDefaultVersions(buildpackTOML dotnetcoreruntime.BuildpackTOML) []Violation {
+	var violations []Violation
+
+	var ids []string
+	for id := range buildpackTOML.Metadata.DefaultVersions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		version := buildpackTOML.Metadata.DefaultVersions[id]
+		subject := fmt.Sprintf("default version %s %s", id, version)
+
+		constraint, err := semver.NewConstraint(version)
+		if err != nil {
+			violations = append(violations, Violation{Subject: subject, Message: fmt.Sprintf("is not a valid version constraint: %s", err)})
+			continue
+		}
+
+		for _, stack := range buildpackTOML.Stacks {
+			satisfied := false
+			for _, dependency := range buildpackTOML.Metadata.Dependencies {
+				if dependency.ID != id || !contains(dependency.Stacks, stack.ID) {
+					continue
+				}
+
+				dependencyVersion, err := semver.NewVersion(dependency.Version)
+				if err == nil && constraint.Check(dependencyVersion) {
+					satisfied = true
+					break
+				}
+			}
+
+			if !satisfied {
+				violations = append(violations, Violation{Subject: subject, Message: fmt.Sprintf("is not satisfied by any dependency for stack %q", stack.ID)})
+			}
+		}
+	}
+
+	return violations
+}
+
+func validateDependencyConstraints(buildpackTOML dotnetcoreruntime.BuildpackTOML) []Violation {
+	var violations []Violation
+
+	for _, dependencyConstraint := range buildpackTOML.Metadata.DependencyConstraints {
+		subject := fmt.Sprintf("dependency constraint %s %s", dependencyConstraint.ID, dependencyConstraint.Constraint)
+
+		constraint, err := semver.NewConstraint(dependencyConstraint.Constraint)
+		if err != nil {
+			violations = append(violations, Violation{Subject: subject, Message: fmt.Sprintf("is not a valid version constraint: %s", err)})
+			continue
+		}
+
+		versions := map[string]bool{}
+		for _, dependency := range buildpackTOML.Metadata.Dependencies {
+			if dependency.ID != dependencyConstraint.ID {
+				continue
+			}
+
+			dependencyVersion, err := semver.NewVersion(dependency.Version)
+			if err == nil && constraint.Check(dependencyVersion) {
+				versions[dependency.Version] = true
+			}
+		}
+
+		if len(versions) != dependencyConstraint.Patches {
+			violations = append(violations, Violation{Subject: subject, Message: fmt.Sprintf("expected %d patches, found %d", dependencyConstraint.Patches, len(versions))})
+		}
+	}
+
+	return violations
+}
+
+func checksumHash(checksum, sha256 string) string {
+	if checksum != "" {
+		if algorithm, hash, found := strings.Cut(checksum, ":"); found && algorithm == "sha256" {
+			return hash
+		}
+	}
+	return sha256
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package validation_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	dotnetcoreruntime "github.com/paketo-buildpacks/dotnet-core-runtime"
+	"github.com/paketo-buildpacks/dotnet-core-runtime/validation"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testValidator(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		buildpackTOML dotnetcoreruntime.BuildpackTOML
+	)
+
+	it.Before(func() {
+		buildpackTOML = dotnetcoreruntime.BuildpackTOML{
+			Metadata: dotnetcoreruntime.BuildpackTOMLMetadata{
+				DefaultVersions: map[string]string{"dotnet-runtime": "6.0.*"},
+				Dependencies: []postal.Dependency{
+					{
+						CPE:          "cpe:2.3:a:microsoft:.net:6.0.12:*:*:*:*:*:*:*",
+						ID:           "dotnet-runtime",
+						PURL:         "pkg:generic/dotnet-runtime@6.0.12?checksum=source-sha-12&download_url=https://example.com/dotnet-runtime-6.0.12.tar.gz",
+						SHA256:       "abcdef0123456789", //nolint:staticcheck
+						Source:       "https://example.com/dotnet-runtime-6.0.12.tar.gz",
+						SourceSHA256: "source-sha-12", //nolint:staticcheck
+						Stacks:       []string{"some-stack", "other-stack"},
+						URI:          "https://deps.example.com/dotnet-runtime_6.0.12_linux_x64_abcdef01.tar.xz",
+						Version:      "6.0.12",
+					},
+					{
+						CPE:          "cpe:2.3:a:microsoft:.net:6.0.13:*:*:*:*:*:*:*",
+						ID:           "dotnet-runtime",
+						PURL:         "pkg:generic/dotnet-runtime@6.0.13?checksum=source-sha-13&download_url=https://example.com/dotnet-runtime-6.0.13.tar.gz",
+						SHA256:       "source-sha-13", //nolint:staticcheck
+						Source:       "https://example.com/dotnet-runtime-6.0.13.tar.gz",
+						SourceSHA256: "source-sha-13", //nolint:staticcheck
+						Stacks:       []string{"some-stack"},
+						URI:          "https://example.com/dotnet-runtime-6.0.13.tar.gz",
+						Version:      "6.0.13",
+					},
+				},
+				DependencyConstraints: []dotnetcoreruntime.DependencyConstraint{
+					{Constraint: "6.0.*", ID: "dotnet-runtime", Patches: 2},
+				},
+			},
+			Stacks: []dotnetcoreruntime.BuildpackTOMLStack{
+				{ID: "some-stack"},
+				{ID: "other-stack"},
+			},
+		}
+	})
+
+	context("Validate", func() {
+		it("returns no violations for consistent metadata", func() {
+			Expect(validation.Validate(buildpackTOML)).To(BeEmpty())
+		})
+
+		context("when the dependency metadata is inconsistent", func() {
+			it.Before(func() {
+				buildpackTOML.Metadata.Dependencies[0].CPE = "cpe:2.3:a:microsoft:.net:6.0.11:*:*:*:*:*:*:*"
+				buildpackTOML.Metadata.Dependencies[0].PURL = "pkg:generic/dotnet-runtime@6.0.12?checksum=other-sha"
+				buildpackTOML.Metadata.Dependencies[0].URI = "https://deps.example.com/dotnet-runtime_6.0.12_linux_x64_12345678.tar.xz"
+				buildpackTOML.Metadata.Dependencies[1].SHA256 = "other-sha" //nolint:staticcheck
+			})
+
+			it("reports every violation", func() {
+				Expect(validation.Validate(buildpackTOML)).To(Equal([]validation.Violation{
+					{Subject: "dependency dotnet-runtime 6.0.12", Message: `cpe "cpe:2.3:a:microsoft:.net:6.0.11:*:*:*:*:*:*:*" does not match version "6.0.12"`},
+					{Subject: "dependency dotnet-runtime 6.0.12", Message: `purl checksum "other-sha" does not match source_sha256 "source-sha-12"`},
+					{Subject: "dependency dotnet-runtime 6.0.12", Message: `uri "https://deps.example.com/dotnet-runtime_6.0.12_linux_x64_12345678.tar.xz" does not embed the sha256 prefix "abcdef01"`},
+					{Subject: "dependency dotnet-runtime 6.0.13", Message: `sha256 "other-sha" does not match source_sha256 "source-sha-13" for an upstream uri`},
+				}))
+			})
+		})
+
+		context("when a dependency is missing checksums and has an invalid version", func() {
+			it.Before(func() {
+				buildpackTOML.Metadata.Dependencies = append(buildpackTOML.Metadata.Dependencies, postal.Dependency{
+					ID:      "other-dependency",
+					Version: "latest",
+					URI:     "some-uri",
+				})
+			})
+
+			it("reports every violation", func() {
+				Expect(validation.Validate(buildpackTOML)).To(Equal([]validation.Violation{
+					{Subject: "dependency other-dependency latest", Message: `version "latest" is not a valid semantic version`},
+					{Subject: "dependency other-dependency latest", Message: "sha256 is missing"},
+					{Subject: "dependency other-dependency latest", Message: "source_sha256 is missing"},
+				}))
+			})
+		})
+
+		context("when a default version is not satisfiable for a stack", func() {
+			it.Before(func() {
+				buildpackTOML.Metadata.DefaultVersions["dotnet-runtime"] = "6.0.13"
+			})
+
+			it("reports the stack", func() {
+				Expect(validation.Validate(buildpackTOML)).To(Equal([]validation.Violation{
+					{Subject: "default version dotnet-runtime 6.0.13", Message: `is not satisfied by any dependency for stack "other-stack"`},
+				}))
+			})
+		})
+
+		context("when a default version is not a valid constraint", func() {
+			it.Before(func() {
+				buildpackTOML.Metadata.DefaultVersions["dotnet-runtime"] = "not-a-version"
+			})
+
+			it("reports the default version", func() {
+				violations := validation.Validate(buildpackTOML)
+				Expect(violations).To(HaveLen(1))
+				Expect(violations[0].String()).To(ContainSubstring("default version dotnet-runtime not-a-version: is not a valid version constraint"))
+			})
+		})
+
+		context("when a dependency constraint does not have the expected number of patches", func() {
+			it.Before(func() {
+				buildpackTOML.Metadata.DependencyConstraints = append(buildpackTOML.Metadata.DependencyConstraints,
+					dotnetcoreruntime.DependencyConstraint{Constraint: "7.0.*", ID: "dotnet-runtime", Patches: 2},
+					dotnetcoreruntime.DependencyConstraint{Constraint: "not-a-constraint", ID: "dotnet-runtime", Patches: 2},
+				)
+			})
+
+			it("reports the constraint", func() {
+				violations := validation.Validate(buildpackTOML)
+				Expect(violations).To(HaveLen(2))
+				Expect(violations[0]).To(Equal(validation.Violation{Subject: "dependency constraint dotnet-runtime 7.0.*", Message: "expected 2 patches, found 0"}))
+				Expect(violations[1].String()).To(ContainSubstring("dependency constraint dotnet-runtime not-a-constraint: is not a valid version constraint"))
+			})
+		})
+	})
+
+	context("ValidateFile", func() {
+		var path string
+
+		it.Before(func() {
+			dir, err := os.MkdirTemp("", "buildpack")
+			Expect(err).NotTo(HaveOccurred())
+			path = filepath.Join(dir, "buildpack.toml")
+
+			Expect(os.WriteFile(path, []byte(`[metadata]
+  [metadata.default-versions]
+    dotnet-runtime = "6.0.*"
+
+[[stacks]]
+  id = "some-stack"
+`), 0600)).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(filepath.Dir(path))).To(Succeed())
+		})
+
+		it("validates the buildpack.toml at the given path", func() {
+			violations, err := validation.ValidateFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(violations).To(Equal([]validation.Violation{
+				{Subject: "default version dotnet-runtime 6.0.*", Message: `is not satisfied by any dependency for stack "some-stack"`},
+			}))
+		})
+
+		context("when the buildpack.toml cannot be parsed", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(path, []byte("%%%"), 0600)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := validation.ValidateFile(path)
+				Expect(err).To(MatchError(ContainSubstring("expected '.' or '=', but got '%' instead")))
+			})
+		})
+	})
+}
@@ -0,0 +1,108 @@
+package dotnetcoreruntime
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+// toolVersionsNames are the tool names under which a .tool-versions file
+// records the .NET SDK version.
+var toolVersionsNames = []string{"dotnet-core", "dotnet"}
+
+type VersionFileParser struct{}
+
+func NewVersionFileParser() VersionFileParser {
+	return VersionFileParser{}
+}
+
+// ParseVersion returns the runtime version recorded in a .dotnet-version file,
+// which holds only the version. It returns an empty string when the file does
+// not exist or is empty.
+func (p VersionFileParser) ParseVersion(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	version := strings.TrimSpace(string(content))
+	if version == "" {
+		return "", nil
+	}
+
+	_, err = semver.NewConstraint(version)
+	if err != nil {
+		return "", fmt.Errorf("invalid version %q in %s: %w", version, path, err)
+	}
+
+	return version, nil
+}
+
+type ToolVersionsParser struct {
+	logger scribe.Emitter
+}
+
+func NewToolVersionsParser(logger scribe.Emitter) ToolVersionsParser {
+	return ToolVersionsParser{
+		logger: logger,
+	}
+}
+
+// ParseSDK returns the SDK version recorded in the dotnet-core (or dotnet)
+// entry of a .tool-versions file, as used by asdf and compatible version
+// managers, whose .NET plugins install an SDK. The first listed value that is
+// a version is used; others, such as system or latest, are skipped with a
+// warning. It returns an empty version when the file does not exist or has no
+// such entry. A .tool-versions file has no roll-forward policy, so the
+// returned rollForward is always empty.
+func (p ToolVersionsParser) ParseSDK(path string) (string, string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", "", nil
+		}
+		return "", "", err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if index := strings.Index(line, "#"); index >= 0 {
+			line = line[:index]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !isToolVersionsName(fields[0]) {
+			continue
+		}
+
+		for _, value := range fields[1:] {
+			if _, err := semver.NewVersion(value); err != nil {
+				p.logger.Subprocess("WARNING: ignoring %s %q in %s: it is not an SDK version", fields[0], value, path)
+				continue
+			}
+			return value, "", nil
+		}
+		return "", "", nil
+	}
+
+	return "", "", nil
+}
+
+func isToolVersionsName(name string) bool {
+	for _, toolName := range toolVersionsNames {
+		if name == toolName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+package dotnetcoreruntime_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	dotnetcoreruntime "github.com/paketo-buildpacks/dotnet-core-runtime"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testVersionFileParser(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		workingDir string
+		parser     dotnetcoreruntime.VersionFileParser
+	)
+
+	it.Before(func() {
+		var err error
+		workingDir, err = os.MkdirTemp("", "working-dir")
+		Expect(err).NotTo(HaveOccurred())
+
+		parser = dotnetcoreruntime.NewVersionFileParser()
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	context("ParseVersion", func() {
+		context("with a .dotnet-version file", func() {
+			var path string
+
+			it.Before(func() {
+				path = filepath.Join(workingDir, ".dotnet-version")
+				Expect(os.WriteFile(path, []byte("6.0.13\n"), 0600)).To(Succeed())
+			})
+
+			it("returns the version in the file", func() {
+				version, err := parser.ParseVersion(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(Equal("6.0.13"))
+			})
+
+			context("when the file is empty", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(path, []byte("\n"), 0600)).To(Succeed())
+				})
+
+				it("returns an empty version", func() {
+					version, err := parser.ParseVersion(path)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(version).To(BeEmpty())
+				})
+			})
+
+			context("failure cases", func() {
+				context("when the version is not a valid version constraint", func() {
+					it.Before(func() {
+						Expect(os.WriteFile(path, []byte("lts\n"), 0600)).To(Succeed())
+					})
+
+					it("returns an error", func() {
+						_, err := parser.ParseVersion(path)
+						Expect(err).To(MatchError(ContainSubstring(`invalid version "lts" in ` + path)))
+					})
+				})
+
+				context("when the file cannot be read", func() {
+					it.Before(func() {
+						Expect(os.Chmod(path, 0000)).To(Succeed())
+					})
+
+					it("returns an error", func() {
+						_, err := parser.ParseVersion(path)
+						Expect(err).To(MatchError(ContainSubstring("permission denied")))
+					})
+				})
+			})
+		})
+
+		context("when the file does not exist", func() {
+			it("returns an empty version", func() {
+				version, err := parser.ParseVersion(filepath.Join(workingDir, ".dotnet-version"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(BeEmpty())
+			})
+		})
+	})
+
+	context("ParseSDK", func() {
+		var (
+			path               string
+			buffer             *bytes.Buffer
+			toolVersionsParser dotnetcoreruntime.ToolVersionsParser
+		)
+
+		it.Before(func() {
+			path = filepath.Join(workingDir, ".tool-versions")
+			Expect(os.WriteFile(path, []byte(`# managed by asdf
+nodejs 18.12.1
+dotnet-core 6.0.404 7.0.102 # the first version is used
+`), 0600)).To(Succeed())
+
+			buffer = bytes.NewBuffer(nil)
+			toolVersionsParser = dotnetcoreruntime.NewToolVersionsParser(scribe.NewEmitter(buffer))
+		})
+
+		it("returns the first dotnet-core SDK version", func() {
+			version, rollForward, err := toolVersionsParser.ParseSDK(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal("6.0.404"))
+			Expect(rollForward).To(BeEmpty())
+		})
+
+		context("when the entry is named dotnet", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(path, []byte("dotnet 7.0.102\n"), 0600)).To(Succeed())
+			})
+
+			it("returns its version", func() {
+				version, _, err := toolVersionsParser.ParseSDK(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(Equal("7.0.102"))
+			})
+		})
+
+		context("when the entry lists values that are not versions", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(path, []byte("dotnet-core latest system 6.0.404\n"), 0600)).To(Succeed())
+			})
+
+			it("skips them with a warning", func() {
+				version, _, err := toolVersionsParser.ParseSDK(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(Equal("6.0.404"))
+				Expect(buffer.String()).To(ContainSubstring(`WARNING: ignoring dotnet-core "latest" in ` + path + `: it is not an SDK version`))
+				Expect(buffer.String()).To(ContainSubstring(`WARNING: ignoring dotnet-core "system" in ` + path + `: it is not an SDK version`))
+			})
+		})
+
+		context("when the entry only lists values that are not versions", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(path, []byte("dotnet-core system\n"), 0600)).To(Succeed())
+			})
+
+			it("returns an empty version", func() {
+				version, _, err := toolVersionsParser.ParseSDK(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(BeEmpty())
+			})
+		})
+
+		context("when there is no dotnet entry", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(path, []byte("nodejs 18.12.1\n"), 0600)).To(Succeed())
+			})
+
+			it("returns an empty version", func() {
+				version, _, err := toolVersionsParser.ParseSDK(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(BeEmpty())
+			})
+		})
+
+		context("when the file does not exist", func() {
+			it("returns an empty version", func() {
+				version, _, err := toolVersionsParser.ParseSDK(filepath.Join(workingDir, "missing", ".tool-versions"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(BeEmpty())
+			})
+		})
+
+		context("when the file cannot be read", func() {
+			it.Before(func() {
+				Expect(os.Chmod(path, 0000)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, _, err := toolVersionsParser.ParseSDK(path)
+				Expect(err).To(MatchError(ContainSubstring("permission denied")))
+			})
+		})
+	})
+}